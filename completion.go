@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// fileFlags take a path, so shells should complete file names for them.
+var fileFlags = map[string]bool{"config": true, "file": true, "state": true}
+
+// completionFlag describes a flag for completion scripts.
+type completionFlag struct {
+	name  string
+	usage string
+	bool  bool
+}
+
+func setupCompletion(flags *flag.FlagSet, _ *globalOptions) func(context.Context, []string) int {
+	return func(_ context.Context, args []string) int {
+		if len(args) != 1 {
+			flags.Usage()
+			return 2
+		}
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout)
+		case "zsh":
+			writeZshCompletion(os.Stdout)
+		case "fish":
+			writeFishCompletion(os.Stdout)
+		default:
+			fmt.Fprintf(os.Stderr, "unsupported shell %q (want bash, zsh or fish)\n", args[0])
+			return 2
+		}
+		return 0
+	}
+}
+
+// commandFlags lists the flags a command accepts, including the global ones.
+func commandFlags(cmd command) []completionFlag {
+	flags, _ := cmd.flagSet()
+	var out []completionFlag
+	flags.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		out = append(out, completionFlag{name: f.Name, usage: f.Usage, bool: ok && b.IsBoolFlag()})
+	})
+	return out
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	return names
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# bash completion for gh-saved-issues")
+	fmt.Fprintln(w, "_gh_saved_issues() {")
+	fmt.Fprintln(w, `  local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `  if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then`)
+	fmt.Fprintf(w, "    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, `  case "$prev" in`)
+	var fileOpts []string
+	for name := range fileFlags {
+		fileOpts = append(fileOpts, "--"+name)
+	}
+	sort.Strings(fileOpts)
+	fmt.Fprintf(w, "    %s)\n", strings.Join(fileOpts, "|"))
+	fmt.Fprintln(w, `      COMPREPLY=($(compgen -f -- "$cur"))`)
+	fmt.Fprintln(w, "      return")
+	fmt.Fprintln(w, "      ;;")
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, `  local cmd="${COMP_WORDS[1]}"`)
+	fmt.Fprintln(w, `  [[ $cmd == -* ]] && cmd=sync`)
+	fmt.Fprintln(w, `  case "$cmd" in`)
+	for _, cmd := range commands {
+		var opts []string
+		for _, f := range commandFlags(cmd) {
+			opts = append(opts, "--"+f.name)
+		}
+		words := strings.Join(opts, " ")
+		if cmd.name == "help" {
+			words = strings.Join(commandNames(), " ")
+		} else if cmd.name == "completion" {
+			words = "bash zsh fish"
+		}
+		fmt.Fprintf(w, "    %s)\n", cmd.name)
+		fmt.Fprintf(w, "      COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", words)
+		fmt.Fprintln(w, "      ;;")
+	}
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _gh_saved_issues gh-saved-issues")
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintln(w, "#compdef gh-saved-issues")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "_gh_saved_issues() {")
+	fmt.Fprintln(w, "  local -a commands")
+	fmt.Fprintln(w, "  commands=(")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "    %s\n", shellQuote(cmd.name+":"+cmd.summary))
+	}
+	fmt.Fprintln(w, "  )")
+	fmt.Fprintln(w, "  if (( CURRENT == 2 )); then")
+	fmt.Fprintln(w, "    _describe 'command' commands")
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, "  case $words[2] in")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "    %s)\n", cmd.name)
+		switch cmd.name {
+		case "help":
+			fmt.Fprintln(w, "      _describe 'command' commands")
+		case "completion":
+			fmt.Fprintln(w, "      _values 'shell' bash zsh fish")
+		default:
+			fmt.Fprint(w, "      _arguments")
+			for _, f := range commandFlags(cmd) {
+				fmt.Fprintf(w, " \\\n        %s", shellQuote(zshFlagSpec(f)))
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "      ;;")
+	}
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "compdef _gh_saved_issues gh-saved-issues")
+}
+
+// zshFlagSpec builds an _arguments spec such as --config[path]:value:_files.
+func zshFlagSpec(f completionFlag) string {
+	desc := strings.NewReplacer("[", `\[`, "]", `\]`, ":", `\:`).Replace(f.usage)
+	spec := "--" + f.name + "[" + desc + "]"
+	if f.name == "var" {
+		spec = "*" + spec
+	}
+	switch {
+	case f.bool:
+	case fileFlags[f.name]:
+		spec += ":" + f.name + ":_files"
+	default:
+		spec += ":" + f.name + ":"
+	}
+	return spec
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for gh-saved-issues")
+	fmt.Fprintln(w, "complete -c gh-saved-issues -f")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c gh-saved-issues -n __fish_use_subcommand -a %s -d %s\n", cmd.name, shellQuote(cmd.summary))
+	}
+	for _, cmd := range commands {
+		cond := shellQuote("__fish_seen_subcommand_from " + cmd.name)
+		switch cmd.name {
+		case "help":
+			fmt.Fprintf(w, "complete -c gh-saved-issues -n %s -a %s\n", cond, shellQuote(strings.Join(commandNames(), " ")))
+			continue
+		case "completion":
+			fmt.Fprintf(w, "complete -c gh-saved-issues -n %s -a 'bash zsh fish'\n", cond)
+			continue
+		}
+		for _, f := range commandFlags(cmd) {
+			line := fmt.Sprintf("complete -c gh-saved-issues -n %s -l %s -d %s", cond, f.name, shellQuote(f.usage))
+			switch {
+			case f.bool:
+			case fileFlags[f.name]:
+				line += " -r -F"
+			default:
+				line += " -r"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
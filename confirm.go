@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+)
+
+// confirmDeletions plans the sync and, if it would delete any saved
+// searches, lists them and asks on the terminal before going ahead.
+func confirmDeletions(ctx context.Context, syncers []*savedsearches.Syncer, configPath string, yes bool) error {
+	results, err := planAll(ctx, syncers, configPath)
+	if err != nil {
+		return err
+	}
+
+	c := confirmer{in: os.Stdin, out: os.Stderr, interactive: stdinIsTerminal()}
+	return c.confirm(results, yes)
+}
+
+// confirmer asks for confirmation of planned deletions. Without an
+// interactive input the answer is no.
+type confirmer struct {
+	in          io.Reader
+	out         io.Writer
+	interactive bool
+}
+
+// confirm lists the deletions in results and asks before going ahead. It
+// returns nil when nothing would be deleted or yes is set.
+func (c confirmer) confirm(results []savedsearches.EntryResult, yes bool) error {
+	var doomed []savedsearches.EntryResult
+	for _, r := range results {
+		if r.Action == savedsearches.ActionDelete || r.Action == savedsearches.ActionRecreate {
+			doomed = append(doomed, r)
+		}
+	}
+	if len(doomed) == 0 || yes {
+		return nil
+	}
+
+	fmt.Fprintln(c.out, "The following saved searches will be deleted:")
+	for _, r := range doomed {
+		note := ""
+		if r.Action == savedsearches.ActionRecreate {
+			note = " (and recreated)"
+		}
+		if r.Account != "" {
+			note += " on " + r.Account
+		}
+		fmt.Fprintf(c.out, "  - %s (%s)%s\n", r.Name, r.ID, note)
+	}
+
+	if !c.interactive {
+		return errors.New("refusing to delete saved searches without confirmation; re-run with --yes")
+	}
+	if !promptYes(c.in, c.out, "Continue? [y/N] ") {
+		return errors.New("aborted")
+	}
+	return nil
+}
+
+// promptYes asks question and reports whether the answer was yes.
+func promptYes(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprint(out, question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(os.Stdin)
+}
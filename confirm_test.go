@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+)
+
+func TestConfirmDeletions(t *testing.T) {
+	deletion := []savedsearches.EntryResult{
+		{Name: "Keep", ID: "SSC_keep", Action: savedsearches.ActionUpdate},
+		{Name: "Old", ID: "SSC_old", Action: savedsearches.ActionDelete},
+	}
+
+	cases := []struct {
+		name        string
+		results     []savedsearches.EntryResult
+		yes         bool
+		interactive bool
+		input       string
+		wantErr     string
+		wantListed  bool
+	}{
+		{name: "no deletions planned", results: deletion[:1]},
+		{name: "yes flag", results: deletion, yes: true},
+		{name: "non-interactive refuses", results: deletion, wantErr: "re-run with --yes", wantListed: true},
+		{name: "answered yes", results: deletion, interactive: true, input: "y\n", wantListed: true},
+		{name: "answered no", results: deletion, interactive: true, input: "\n", wantErr: "aborted", wantListed: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := confirmer{in: strings.NewReader(tc.input), out: &out, interactive: tc.interactive}
+			err := c.confirm(tc.results, tc.yes)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if listed := strings.Contains(out.String(), "Old (SSC_old)"); listed != tc.wantListed {
+				t.Fatalf("expected listed=%v, got output %q", tc.wantListed, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+)
+
+// setupDiscover finds the current persisted query IDs in GitHub's web UI,
+// or in pages and bundles saved from it, and caches them for the host.
+func setupDiscover(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	printOnly := flags.Bool("print", false, "print the IDs without caching them")
+	return func(ctx context.Context, args []string) int {
+		host, source, queries, content, err := discoverQueries(ctx, opts, args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		builtin := savedsearches.DefaultPersistedQueries()
+		var missing []string
+		for _, op := range savedsearches.PersistedOperations {
+			id := queries.ID(op)
+			switch {
+			case id == "":
+				if builtin.ID(op) != "" {
+					missing = append(missing, op)
+				}
+				fmt.Printf("%s\tnot found\n", op)
+			case id == builtin.ID(op):
+				fmt.Printf("%s\t%s (built in)\n", op, id)
+			case builtin.ID(op) == "":
+				fmt.Printf("%s\t%s\n", op, id)
+			default:
+				fmt.Printf("%s\t%s (was %s)\n", op, id, builtin.ID(op))
+			}
+		}
+		if len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "Keeping the built-in IDs for %s.\n", strings.Join(missing, ", "))
+		}
+		if queries.List == "" {
+			fmt.Fprintln(os.Stderr, "Without a list query, plan can't tell which searches are unchanged and import needs a file.")
+		}
+		if *printOnly {
+			return 0
+		}
+
+		path, err := savedsearches.ResolveQueryCachePath()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		cache, err := savedsearches.LoadQueryCache(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		cache.Store(host, savedsearches.NewCachedQueries(queries, source, content))
+		if err := savedsearches.SaveQueryCache(path, cache); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Cached the IDs for %s in %s.\n", host, path)
+		return 0
+	}
+}
+
+// discoverQueries reads the IDs from saved files, or fetches a page and the
+// bundles it loads. With no arguments it fetches the host's issues
+// dashboard.
+func discoverQueries(ctx context.Context, opts *globalOptions, args []string) (host, source string, queries savedsearches.PersistedQueries, content []byte, err error) {
+	fetch := len(args) == 0 || len(args) == 1 && (strings.HasPrefix(args[0], "https://") || strings.HasPrefix(args[0], "http://"))
+	if fetch {
+		client, err := newClient(ctx, opts)
+		if err != nil {
+			return "", "", queries, nil, err
+		}
+		source = "https://" + client.Host() + "/issues"
+		if len(args) == 1 {
+			source = args[0]
+		}
+		queries, content, err = client.DiscoverQueries(ctx, source)
+		return client.Host(), source, queries, content, err
+	}
+
+	for _, path := range args {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", queries, nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		content = append(content, b...)
+		queries = queries.Merge(savedsearches.DiscoverPersistedQueries(b))
+	}
+	if queries == (savedsearches.PersistedQueries{}) {
+		return "", "", queries, nil, fmt.Errorf("no saved search queries found in %s", strings.Join(args, ", "))
+	}
+	return opts.host(), strings.Join(args, ", "), queries, content, nil
+}
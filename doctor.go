@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+)
+
+// checkStatus is the outcome of one doctor check.
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+// check is one line of doctor output. hint says how to fix a warning or
+// failure.
+type check struct {
+	status checkStatus
+	name   string
+	detail string
+	hint   string
+}
+
+// doctorTimeout bounds the network checks for each account.
+const doctorTimeout = 30 * time.Second
+
+// setupDoctor checks the config, credentials and GitHub endpoints, printing
+// a pass, warn or fail line for each. It exits non-zero if any check fails.
+func setupDoctor(_ *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	return func(ctx context.Context, _ []string) int {
+		checks, cfg := checkConfig(opts)
+
+		accounts := cfg.UsedAccounts()
+		if opts.account != "" {
+			accounts = []string{opts.account}
+		}
+		if len(accounts) == 0 {
+			accounts = []string{""}
+		}
+		for _, name := range accounts {
+			checks = append(checks, checkAccount(ctx, opts, cfg, name)...)
+		}
+
+		color := colorEnabled()
+		failed := false
+		for _, c := range checks {
+			printCheck(c, color)
+			if c.status == checkFail {
+				failed = true
+			}
+		}
+		if failed {
+			return 1
+		}
+		return 0
+	}
+}
+
+// checkConfig resolves and loads the config.
+func checkConfig(opts *globalOptions) ([]check, savedsearches.Config) {
+	var cfg savedsearches.Config
+	path, err := savedsearches.ResolveConfigPath(opts.config)
+	if err != nil {
+		return []check{{checkFail, "config", err.Error(), "pass --config PATH"}}, cfg
+	}
+
+	cfg, err = savedsearches.LoadConfig(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return []check{{checkFail, "config", path + " does not exist", "create it, or pass --config PATH"}}, cfg
+	case err != nil:
+		return []check{{checkFail, "config", err.Error(), "fix the YAML; gh saved-issues validate shows more"}}, cfg
+	}
+	entries := "entries"
+	if len(cfg.Searches) == 1 {
+		entries = "entry"
+	}
+	return []check{{checkPass, "config", fmt.Sprintf("%s (%d %s)", path, len(cfg.Searches), entries), ""}}, cfg
+}
+
+// checkAccount checks the credentials and endpoints of one account. Later
+// checks are skipped when the ones they depend on fail.
+func checkAccount(ctx context.Context, opts *globalOptions, cfg savedsearches.Config, name string) []check {
+	prefix := ""
+	if name != "" {
+		prefix = name + " "
+	}
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	client, err := accountClient(ctx, opts, cfg, name)
+	if err != nil {
+		hint := "run gh auth login, or set GH_TOKEN"
+		if name != "" && name != savedsearches.DefaultAccount {
+			hint = "check the account's token and cookie settings"
+		}
+		return []check{{checkFail, prefix + "token", err.Error(), hint}}
+	}
+
+	var checks []check
+	info, err := client.TokenInfo(ctx)
+	if err != nil {
+		hint := "the token may be expired or revoked; run gh auth refresh"
+		if errors.As(err, new(*url.Error)) {
+			hint = "could not reach the API; check your network or VPN, and --hostname"
+		}
+		return append(checks, check{checkFail, prefix + "token", err.Error(), hint})
+	}
+	checks = append(checks, check{checkPass, prefix + "token", "authenticated as " + info.Login, ""})
+	checks = append(checks, checkScopes(prefix, cfg, info.Scopes))
+
+	if err := client.Ping(ctx); err != nil {
+		return append(checks, check{checkFail, prefix + "endpoint", err.Error(), "check your network or VPN, and --hostname"})
+	}
+	checks = append(checks, check{checkPass, prefix + "endpoint", client.Host() + " is reachable", ""})
+
+	login, err := client.SessionLogin(ctx)
+	switch {
+	case errors.Is(err, savedsearches.ErrSessionExpired):
+		return append(checks, check{checkFail, prefix + "cookie", err.Error(), "sign in to GitHub in your browser, then run gh saved-issues login again"})
+	case err != nil:
+		return append(checks, check{checkFail, prefix + "cookie", err.Error(), "run gh saved-issues login"})
+	case !strings.EqualFold(login, info.Login):
+		checks = append(checks, check{checkWarn, prefix + "cookie", fmt.Sprintf("signed in as %s, but the token is for %s", login, info.Login), "use a cookie and token for the same user"})
+	default:
+		checks = append(checks, check{checkPass, prefix + "cookie", "signed in as " + login, ""})
+	}
+
+	for _, op := range savedsearches.PersistedOperations {
+		err := client.CheckPersistedQuery(ctx, op)
+		if errors.Is(err, savedsearches.ErrListUnavailable) {
+			checks = append(checks, check{checkWarn, prefix + op + " query", "not configured", "run gh saved-issues discover so plan can skip unchanged searches"})
+			continue
+		}
+		if err != nil {
+			checks = append(checks, check{checkFail, prefix + op + " query", err.Error(), "GitHub may have changed its web UI; run gh saved-issues discover, or update gh-saved-issues"})
+			continue
+		}
+		checks = append(checks, check{checkPass, prefix + op + " query", "recognized by GitHub", ""})
+	}
+	return checks
+}
+
+// checkScopes warns about OAuth scopes the config may need. Fine-grained
+// tokens don't report scopes, so they can't be checked.
+func checkScopes(prefix string, cfg savedsearches.Config, scopes []string) check {
+	if scopes == nil {
+		return check{checkWarn, prefix + "scopes", "not reported (fine-grained or app token)", "make sure it can read the repositories you search"}
+	}
+
+	var missing []string
+	if !slices.Contains(scopes, "repo") {
+		missing = append(missing, "repo")
+	}
+	if cfg.UsesVars("orgs") && !slices.Contains(scopes, "read:org") {
+		missing = append(missing, "read:org")
+	}
+	if len(missing) > 0 {
+		return check{checkWarn, prefix + "scopes", "missing " + strings.Join(missing, ", "), "gh auth refresh -s " + strings.Join(missing, ",")}
+	}
+	return check{checkPass, prefix + "scopes", strings.Join(scopes, ", "), ""}
+}
+
+func printCheck(c check, color bool) {
+	label := [...]string{"PASS", "WARN", "FAIL"}[c.status]
+	if color {
+		code := [...]string{"\033[32m", "\033[33m", "\033[31m"}[c.status]
+		label = code + label + "\033[0m"
+	}
+	fmt.Fprintf(os.Stdout, "%s  %s: %s\n", label, c.name, c.detail)
+	if c.hint != "" {
+		fmt.Fprintf(os.Stdout, "      hint: %s\n", c.hint)
+	}
+}
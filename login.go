@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+	"golang.org/x/term"
+)
+
+// setupLogin stores the session cookie for the host, or --account, in a
+// secret store so it doesn't have to live in the environment.
+func setupLogin(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	store := flags.String("store", "", "where to keep the cookie: "+strings.Join(savedsearches.SecretStoreKinds, ", ")+" (default: $GH_SAVED_ISSUES_STORE, or the keyring when available)")
+	remove := flags.Bool("delete", false, "remove the stored cookie instead")
+	return func(_ context.Context, _ []string) int {
+		secrets, err := savedsearches.OpenSecretStore(*store)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		host := opts.host()
+		name := savedsearches.SessionSecretName(host, opts.account)
+		if *remove {
+			if err := secrets.Delete(name); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			fmt.Printf("Removed the session cookie for %s.\n", host)
+			return 0
+		}
+
+		cookie, err := readCookie(host)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := secrets.Set(name, cookie); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Stored the session cookie for %s.\n", host)
+		return 0
+	}
+}
+
+// readCookie prompts for the session cookie without echoing it, or reads
+// it from stdin when that isn't a terminal. A bare value is taken to be
+// the user_session cookie.
+func readCookie(host string) (string, error) {
+	var raw string
+	if stdinIsTerminal() {
+		fmt.Fprintf(os.Stderr, "Paste the user_session cookie for %s (from your browser's devtools): ", host)
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("read cookie: %w", err)
+		}
+		raw = string(b)
+	} else {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("read cookie: %w", err)
+		}
+		raw = string(b)
+	}
+
+	cookie := strings.TrimSpace(raw)
+	if cookie == "" {
+		return "", errors.New("no cookie given")
+	}
+	if !strings.Contains(cookie, "=") {
+		cookie = "user_session=" + cookie
+	}
+	return cookie, nil
+}
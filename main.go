@@ -2,35 +2,399 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
 )
 
+// command is a gh saved-issues subcommand.
+type command struct {
+	name    string
+	args    string // positional arguments, for the usage line
+	summary string
+
+	// local commands don't read the config, so they don't get the global
+	// --config and --var flags.
+	local bool
+
+	// setup registers the command's flags and returns the function that runs
+	// it with the remaining positional arguments.
+	setup func(flags *flag.FlagSet, opts *globalOptions) func(ctx context.Context, args []string) int
+}
+
+// globalOptions holds the flags shared by every command.
+type globalOptions struct {
+	config   string
+	hostname string
+	account  string
+	vars     map[string]any
+	verbose  bool
+}
+
+// loadConfig loads the config for settings like the host and accounts. An
+// unreadable config is treated as empty here; the command reports it when
+// it loads the config itself.
+func (o *globalOptions) loadConfig() savedsearches.Config {
+	var cfg savedsearches.Config
+	if path, err := savedsearches.ResolveConfigPath(o.config); err == nil {
+		cfg, _ = savedsearches.LoadConfig(path)
+	}
+	return cfg
+}
+
+// host returns the GitHub host to talk to: the --account's host, or else
+// --hostname, the config's host key or GH_HOST.
+func (o *globalOptions) host() string {
+	cfg := o.loadConfig()
+	if account, err := cfg.LookupAccount(o.account); err == nil && account.Host != "" {
+		return savedsearches.NormalizeHost(account.Host)
+	}
+	return savedsearches.ResolveHost(o.hostname, cfg)
+}
+
+// logger returns a debug logger on stderr with -v, otherwise nil.
+func (o *globalOptions) logger() *slog.Logger {
+	if !o.verbose {
+		return nil
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// commands is every subcommand in the order they are listed in help. It is
+// filled in by init since help and completion refer back to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "sync", summary: "create, update and delete saved searches to match the config", setup: setupSync},
+		{name: "plan", summary: "show what sync would change without changing anything", setup: setupPlan},
+		{name: "reset", summary: "delete every configured saved search", setup: setupReset},
+		{name: "recreate", summary: "delete and recreate every configured saved search", setup: setupRecreate},
+		{name: "import", args: "[FILE]", summary: "add searches from an exported JSON/CSV file to the config", setup: setupImport},
+		{name: "export", summary: "write the searches as Markdown, HTML bookmarks, CSV or JSON", setup: setupExport},
+		{name: "validate", summary: "check the config renders and every query is valid", setup: setupValidate},
+		{name: "list", summary: "list the configured searches", setup: setupList},
+		{name: "lint", summary: "report problems in every rendered query", setup: setupLint},
+		{name: "fmt", summary: "rewrite the config's queries in canonical form", setup: setupFmt},
+		{name: "preview", args: "NAME", summary: "show the top results for a search", setup: setupPreview},
+		{name: "status", summary: "show the open result count for every search", setup: setupStatus},
+		{name: "watch", summary: "report new results for every search as they appear", setup: setupWatch},
+		{name: "open", args: "NAME", summary: "open a search in the browser", setup: setupOpen},
+		{name: "doctor", summary: "check the config, token, cookie and GitHub endpoints", setup: setupDoctor},
+		{name: "login", summary: "store the session cookie in the system keyring or an encrypted file", setup: setupLogin},
+		{name: "discover", args: "[FILE...|URL]", summary: "find the current web UI query IDs and cache them", setup: setupDiscover},
+		{name: "help", args: "[COMMAND]", summary: "show help for a command", local: true, setup: setupHelp},
+		{name: "completion", args: "bash|zsh|fish", summary: "print a shell completion script", local: true, setup: setupCompletion},
+	}
+}
+
 func main() {
-	ctx := context.Background()
+	os.Exit(run(context.Background(), os.Args[1:]))
+}
 
-	configFlag := flag.String("config", "", "path to config file (default: $XDG_HOME/.github-searches.yaml or $XDG_CONFIG_HOME/.github-searches.yaml)")
-	recreate := flag.Bool("recreate", false, "recreate all saved searches (delete existing first)")
-	reset := flag.Bool("reset", false, "delete configured saved searches without recreating them")
-	flag.Parse()
+// run dispatches to a subcommand. With no command, or when the arguments
+// start with a flag, it runs sync so `gh saved-issues --config x` keeps
+// working.
+func run(ctx context.Context, args []string) int {
+	name := "sync"
+	if len(args) > 0 {
+		switch {
+		case args[0] == "-h" || args[0] == "-help" || args[0] == "--help":
+			usage(os.Stdout)
+			return 0
+		case !strings.HasPrefix(args[0], "-"):
+			name, args = args[0], args[1:]
+		}
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage(os.Stderr)
+		return 2
+	}
 
-	configPath, err := savedsearches.ResolveConfigPath(*configFlag)
+	flags, runner := cmd.flagSet()
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	return runner(ctx, flags.Args())
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// flagSet builds the command's flags, including the global ones.
+func (c command) flagSet() (*flag.FlagSet, func(context.Context, []string) int) {
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	opts := &globalOptions{}
+	if !c.local {
+		flags.StringVar(&opts.config, "config", "", "path to config file (default: $XDG_HOME/.github-searches.yaml or $XDG_CONFIG_HOME/.github-searches.yaml)")
+		flags.StringVar(&opts.hostname, "hostname", "", "GitHub host to use, for GitHub Enterprise (default: the config's host, $GH_HOST or github.com)")
+		flags.StringVar(&opts.account, "account", "", "only use searches of this configured account (\""+savedsearches.DefaultAccount+"\" for searches without one)")
+		opts.vars = varFlag(flags)
+		flags.BoolVar(&opts.verbose, "v", false, "log sync steps and GraphQL request metadata to stderr (credentials redacted)")
+	}
+	runner := c.setup(flags, opts)
+	flags.Usage = func() { c.usage(flags) }
+	return flags, runner
+}
+
+func (c command) usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	line := "gh saved-issues " + c.name
+	hasFlags := false
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		line += " [flags]"
+	}
+	if c.args != "" {
+		line += " " + c.args
+	}
+	fmt.Fprintf(out, "Usage: %s\n\n%s\n", line, upperFirst(c.summary))
+	if hasFlags {
+		fmt.Fprintln(out, "\nFlags:")
+		flags.PrintDefaults()
+	}
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gh saved-issues [command] [flags]")
+	fmt.Fprintln(w, "\nWith no command, runs sync.")
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nRun 'gh saved-issues help COMMAND' for a command's flags.")
+}
+
+func setupHelp(_ *flag.FlagSet, _ *globalOptions) func(context.Context, []string) int {
+	return func(_ context.Context, args []string) int {
+		if len(args) == 0 {
+			usage(os.Stdout)
+			return 0
+		}
+		cmd, ok := findCommand(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+			return 2
+		}
+		cmdFlags, _ := cmd.flagSet()
+		cmdFlags.SetOutput(os.Stdout)
+		cmdFlags.Usage()
+		return 0
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// newClient builds the GraphQL client for --account, logging requests with
+// -v.
+func newClient(ctx context.Context, opts *globalOptions) (*savedsearches.GraphQLClient, error) {
+	return accountClient(ctx, opts, opts.loadConfig(), opts.account)
+}
+
+// accountClient builds the GraphQL client for a configured account. "" and
+// "default" use the token gh or the environment provide for the host.
+func accountClient(ctx context.Context, opts *globalOptions, cfg savedsearches.Config, name string) (*savedsearches.GraphQLClient, error) {
+	account, err := cfg.LookupAccount(name)
+	if err != nil {
+		return nil, err
+	}
+
+	host := savedsearches.ResolveHost(opts.hostname, cfg)
+	var client *savedsearches.GraphQLClient
+	if name == "" || name == savedsearches.DefaultAccount {
+		client, err = savedsearches.NewGraphQLClientForHost(ctx, host)
+	} else {
+		client, err = savedsearches.NewAccountClient(ctx, name, account, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// A broken query cache only loses discovered IDs; the built-in,
+	// config and environment ones still work.
+	queries, err := savedsearches.ResolvePersistedQueries(cfg, client.Host())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; using the built-in and configured query IDs\n", err)
+	}
+	client.SetPersistedQueries(queries)
+	if logger := opts.logger(); logger != nil {
+		client.SetLogger(logger)
+	}
+	return client, nil
+}
+
+// optionalClient returns an account's client, or nil when there are no
+// credentials. Commands that only render the config need it just for
+// built-in vars like login, so a missing token is not fatal for them.
+func optionalClient(ctx context.Context, opts *globalOptions, cfg savedsearches.Config, name string) savedsearches.Client {
+	client, err := accountClient(ctx, opts, cfg, name)
+	if err != nil {
+		// A nil *GraphQLClient must not become a non-nil Client.
+		return nil
+	}
+	return client
+}
+
+// colorEnabled reports whether stdout is a terminal and NO_COLOR is unset.
+func colorEnabled() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runAccounts returns the accounts a command covers: the one given by
+// --account, or every account the config's searches use.
+func runAccounts(opts *globalOptions, cfg savedsearches.Config) []string {
+	if opts.account != "" {
+		return []string{opts.account}
+	}
+	if accounts := cfg.UsedAccounts(); len(accounts) > 0 {
+		return accounts
+	}
+	return []string{""}
+}
+
+// accountView is the rendered searches of one account and the client to
+// run them with.
+type accountView struct {
+	name      string // "" for the default account
+	host      string
+	client    *savedsearches.GraphQLClient
+	clientErr error // why client is nil
+	searches  []savedsearches.RenderedSearch
+}
+
+// requireClient returns the view's client, or why it couldn't be built.
+func (v accountView) requireClient() (*savedsearches.GraphQLClient, error) {
+	switch {
+	case v.client != nil:
+		return v.client, nil
+	case v.name != "":
+		return nil, fmt.Errorf("init client for account %s: %w", v.name, v.clientErr)
+	default:
+		return nil, fmt.Errorf("init client: %w", v.clientErr)
+	}
+}
+
+// findViewSearch finds a search by name across every account's searches,
+// returning it with the view of the account it belongs to.
+func findViewSearch(views []accountView, name string) (accountView, savedsearches.RenderedSearch, error) {
+	var all []savedsearches.RenderedSearch
+	for _, view := range views {
+		all = append(all, view.searches...)
+	}
+	search, err := savedsearches.FindSearch(all, name)
+	if err != nil {
+		return accountView{}, savedsearches.RenderedSearch{}, err
+	}
+	for _, view := range views {
+		if view.name == search.Account {
+			return view, search, nil
+		}
+	}
+	return accountView{}, savedsearches.RenderedSearch{}, fmt.Errorf("no account loaded for %s", search.Name)
+}
+
+// loadAccountViews renders the searches of every account the command
+// covers, resolving built-in vars like login through each account's own
+// client. Unless required is set, an account without credentials gets a
+// nil client, for commands that only render the searches or only need the
+// client of one of them.
+func loadAccountViews(ctx context.Context, opts *globalOptions, required bool) ([]accountView, error) {
+	configPath, err := savedsearches.ResolveConfigPath(opts.config)
 	if err != nil {
-		log.Fatalf("resolve config path: %v", err)
+		return nil, fmt.Errorf("resolve config path: %w", err)
 	}
 
-	client, err := savedsearches.NewGraphQLClient(ctx, "")
+	cfg, err := savedsearches.LoadConfig(configPath)
 	if err != nil {
-		log.Fatalf("init client: %v", err)
+		return nil, err
 	}
 
-	syncer := savedsearches.NewSyncer(client, *recreate, *reset)
-	if err := syncer.Sync(ctx, configPath); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	var views []accountView
+	for _, name := range runAccounts(opts, cfg) {
+		account, err := cfg.LookupAccount(name)
+		if err != nil {
+			return nil, err
+		}
+		if name == savedsearches.DefaultAccount {
+			name = ""
+		}
+
+		view := accountView{name: name, host: savedsearches.ResolveHost(opts.hostname, cfg)}
+		if account.Host != "" {
+			view.host = savedsearches.NormalizeHost(account.Host)
+		}
+
+		// A nil *GraphQLClient must not become a non-nil Client.
+		var vars savedsearches.Client
+		view.client, view.clientErr = accountClient(ctx, opts, cfg, name)
+		if view.client != nil {
+			view.host, vars = view.client.Host(), view.client
+		} else if required {
+			_, err := view.requireClient()
+			return nil, err
+		}
+
+		globals, err := cfg.ResolveVars(ctx, vars, opts.vars)
+		if err != nil {
+			return nil, err
+		}
+		view.searches, err = savedsearches.RenderAccountSearches(cfg, name, globals)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
 	}
+	return views, nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// varFlag registers the repeatable --var key=value flag on flags.
+func varFlag(flags *flag.FlagSet) map[string]any {
+	vars := map[string]any{}
+	flags.Func("var", "set a template var as key=value (repeatable; overrides config and environment vars, but not an entry's own vars)", func(raw string) error {
+		key, val, err := savedsearches.ParseVar(raw)
+		if err != nil {
+			return err
+		}
+		vars[key] = val
+		return nil
+	})
+	return vars
 }
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+)
+
+func TestFindViewSearch(t *testing.T) {
+	views := []accountView{
+		{host: "github.com", searches: []savedsearches.RenderedSearch{{Name: "Mine", Query: "author:alice"}}},
+		{name: "work", host: "ghe.example.com", searches: []savedsearches.RenderedSearch{{Name: "Work reviews", Query: "review-requested:alice-corp", Account: "work"}}},
+	}
+
+	view, search, err := findViewSearch(views, "reviews")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if view.name != "work" || view.host != "ghe.example.com" || search.Query != "review-requested:alice-corp" {
+		t.Fatalf("expected the work account's search, got %+v %+v", view, search)
+	}
+
+	if _, _, err := findViewSearch(views, "missing"); err == nil {
+		t.Fatalf("expected an error for an unknown search")
+	}
+}
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"sort"
+	"strings"
+)
+
+// node is a token or a parenthesised group of nodes.
+type node struct {
+	tok   Token
+	group []node // set for groups; tok is the opening paren
+}
+
+func (n node) isGroup() bool { return n.tok.Kind == LParen }
+
+// Format returns the canonical form of a query: whitespace is collapsed,
+// and when the top level is a plain list of terms (no AND, OR or NOT) its
+// qualifiers are sorted by name, followed by groups and then sort:
+// qualifiers. Words are never re-cased, since a lower-case and/or is
+// searched as text. Queries that don't tokenize or have
+// unbalanced parentheses only have their whitespace collapsed.
+func Format(q string) string {
+	tokens, err := Tokenize(q)
+	if err != nil {
+		return strings.Join(strings.Fields(q), " ")
+	}
+
+	nodes, rest, ok := parseNodes(tokens)
+	if !ok || len(rest) != 0 {
+		return joinTokens(tokens)
+	}
+
+	return render(reorder(nodes))
+}
+
+// parseNodes builds the group tree, returning the tokens left after the
+// closing paren of the current group. ok is false for an unclosed group.
+func parseNodes(tokens []Token) ([]node, []Token, bool) {
+	var nodes []node
+	for len(tokens) > 0 {
+		tok := tokens[0]
+		tokens = tokens[1:]
+		switch tok.Kind {
+		case RParen:
+			return nodes, append([]Token{tok}, tokens...), true
+		case LParen:
+			children, rest, ok := parseNodes(tokens)
+			if !ok || len(rest) == 0 {
+				return nil, nil, false
+			}
+			nodes = append(nodes, node{tok: tok, group: children})
+			tokens = rest[1:]
+		default:
+			nodes = append(nodes, node{tok: tok})
+		}
+	}
+	return nodes, nil, true
+}
+
+// reorder sorts the qualifiers of an implicit-AND list. Lists containing
+// boolean operators are returned unchanged since order matters there, and
+// so are lists with and/or in another case, which read like operators.
+func reorder(nodes []node) []node {
+	for _, n := range nodes {
+		if n.tok.IsOperator() {
+			return nodes
+		}
+		if n.tok.Kind == Term {
+			switch strings.ToUpper(n.tok.Text) {
+			case "AND", "OR":
+				return nodes
+			}
+		}
+	}
+
+	var terms, quals, groups, sorts []node
+	for _, n := range nodes {
+		switch {
+		case n.isGroup():
+			groups = append(groups, n)
+		case n.tok.Kind == Qualifier && n.tok.Key == "sort":
+			sorts = append(sorts, n)
+		case n.tok.Kind == Qualifier:
+			quals = append(quals, n)
+		default:
+			terms = append(terms, n)
+		}
+	}
+	sort.SliceStable(quals, func(i, j int) bool { return quals[i].tok.Key < quals[j].tok.Key })
+
+	out := make([]node, 0, len(nodes))
+	out = append(out, terms...)
+	out = append(out, quals...)
+	out = append(out, groups...)
+	return append(out, sorts...)
+}
+
+func render(nodes []node) string {
+	parts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if n.isGroup() {
+			parts = append(parts, "("+render(n.group)+")")
+			continue
+		}
+		parts = append(parts, n.tok.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// joinTokens writes tokens with single spaces, without padding inside parens.
+func joinTokens(tokens []Token) string {
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && tok.Kind != RParen && tokens[i-1].Kind != LParen {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok.Text)
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package query
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"whitespace", "  is:pr   state:open\n", "is:pr state:open"},
+		{"qualifier order", "state:open is:pr archived:false", "archived:false is:pr state:open"},
+		{"terms first", "state:open flaky is:pr", "flaky is:pr state:open"},
+		{"groups and sort last", "sort:updated-desc (repo:a/b OR repo:c/d) org:x is:pr", "is:pr org:x (repo:a/b OR repo:c/d) sort:updated-desc"},
+		{"group spacing", "is:pr (  repo:a/b   OR repo:c/d )", "is:pr (repo:a/b OR repo:c/d)"},
+		{"lower-case or stays text", "is:pr (repo:a/b or repo:c/d)", "is:pr (repo:a/b or repo:c/d)"},
+		{"lower-case and stays text", "fix and test is:issue", "fix and test is:issue"},
+		{"top level operators keep order", "state:open OR is:pr", "state:open OR is:pr"},
+		{"lower not is text", "is:issue not planned", "not planned is:issue"},
+		{"negated keeps key order", "-author:a is:pr author:b", "-author:a author:b is:pr"},
+		{"unbalanced", "is:pr  (state:open", "is:pr (state:open"},
+		{"quoted values", `label:"good first issue"  is:issue`, `is:issue label:"good first issue"`},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Format(tc.in); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	q := "org:my-org ((assignee:alice AND is:issue) OR (is:pr AND author:alice)) (is:open OR updated:>@today-30d) sort:updated-desc"
+	once := Format(q)
+	if Format(once) != once {
+		t.Fatalf("expected format to be idempotent: %q vs %q", once, Format(once))
+	}
+	if once != q {
+		t.Fatalf("expected canonical query unchanged, got %q", once)
+	}
+}
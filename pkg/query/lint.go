@@ -0,0 +1,150 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity ranks a diagnostic.
+type Severity int
+
+const (
+	// Warning marks a query that works but is probably not what was meant.
+	Warning Severity = iota
+	// Error marks a query GitHub will reject or misinterpret.
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a single problem found in a query.
+type Diagnostic struct {
+	Severity Severity
+	Pos      int
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: column %d: %s", d.Severity, d.Pos+1, d.Message)
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks a query for syntax errors, unknown qualifiers and malformed
+// qualifier values. An empty query has no diagnostics.
+func Lint(q string) []Diagnostic {
+	if strings.TrimSpace(q) == "" {
+		return nil
+	}
+
+	tokens, err := Tokenize(q)
+	if err != nil {
+		se := err.(*SyntaxError)
+		return []Diagnostic{{Severity: Error, Pos: se.Pos, Message: se.Msg}}
+	}
+
+	var diags []Diagnostic
+	add := func(sev Severity, pos int, format string, args ...any) {
+		diags = append(diags, Diagnostic{Severity: sev, Pos: pos, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var open []int
+	restricted := false
+	sorts := 0
+	for i, tok := range tokens {
+		var prev, next *Token
+		if i > 0 {
+			prev = &tokens[i-1]
+		}
+		if i+1 < len(tokens) {
+			next = &tokens[i+1]
+		}
+
+		switch tok.Kind {
+		case LParen:
+			open = append(open, tok.Pos)
+			if next != nil && next.Kind == RParen {
+				add(Warning, tok.Pos, "empty parentheses")
+			}
+		case RParen:
+			if len(open) == 0 {
+				add(Error, tok.Pos, "unmatched ')'")
+				continue
+			}
+			open = open[:len(open)-1]
+		case And, Or:
+			if prev == nil || prev.IsOperator() || prev.Kind == LParen {
+				add(Error, tok.Pos, "%s has no term before it", tok.Kind)
+			}
+			if next == nil || next.Kind == And || next.Kind == Or || next.Kind == RParen {
+				add(Error, tok.Pos, "%s has no term after it", tok.Kind)
+			}
+		case Not:
+			if next == nil || next.IsOperator() || next.Kind == RParen {
+				add(Error, tok.Pos, "NOT has no term after it")
+			}
+		case Term:
+			if lower := strings.ToLower(tok.Text); lower == "and" || lower == "or" || lower == "not" {
+				if tok.Text != strings.ToUpper(tok.Text) {
+					add(Warning, tok.Pos, "%q is searched as text; boolean operators must be upper case", tok.Text)
+				}
+			}
+		case Qualifier:
+			diags = append(diags, lintQualifier(tok)...)
+			switch {
+			case tok.Key == "sort":
+				sorts++
+				if sorts == 2 {
+					add(Warning, tok.Pos, "multiple sort qualifiers; only one is used")
+				}
+			case !tok.Negated && (tok.Key == "is" || tok.Key == "type") && (strings.EqualFold(tok.Value, "issue") || strings.EqualFold(tok.Value, "pr")):
+				restricted = true
+			}
+		}
+	}
+
+	for _, pos := range open {
+		add(Error, pos, "unclosed '('")
+	}
+
+	if !restricted {
+		add(Warning, 0, "query matches both issues and pull requests; add is:issue or is:pr")
+	}
+
+	return diags
+}
+
+// lintQualifier checks one qualifier. Unknown qualifiers are only warnings,
+// even with a suggestion: GitHub adds qualifiers faster than the table
+// below is updated.
+func lintQualifier(tok Token) []Diagnostic {
+	check, ok := qualifiers[tok.Key]
+	if !ok {
+		if s := suggest(tok.Key); s != "" {
+			return []Diagnostic{{Severity: Warning, Pos: tok.Pos, Message: fmt.Sprintf("unknown qualifier %q (did you mean %q?)", tok.Key, s)}}
+		}
+		return []Diagnostic{{Severity: Warning, Pos: tok.Pos, Message: fmt.Sprintf("unknown qualifier %q", tok.Key)}}
+	}
+
+	if tok.Value == "" {
+		return []Diagnostic{{Severity: Error, Pos: tok.Pos, Message: fmt.Sprintf("qualifier %q has no value", tok.Key)}}
+	}
+
+	if msg := check(tok.Value); msg != "" {
+		return []Diagnostic{{Severity: Error, Pos: tok.Pos, Message: fmt.Sprintf("invalid value %q for %s: %s", tok.Value, tok.Key, msg)}}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintValidQueries(t *testing.T) {
+	queries := []string{
+		"is:issue state:open archived:false assignee:@me sort:updated-desc org:Kong",
+		"org:my-org ((assignee:alice AND is:issue) OR (is:pr AND author:alice)) (is:open OR updated:>@today-30d) sort:updated-desc",
+		`is:pr state:open (repo:a/b OR repo:c/d) -author:app/renovate draft:false label:"needs review"`,
+		"is:pr created:2026-01-01..2026-01-31 comments:>5 review:approved",
+		"type:issue updated:>=2026-10-09T10:00:00Z in:title,body no:assignee",
+	}
+	for _, q := range queries {
+		if diags := Lint(q); len(diags) != 0 {
+			t.Fatalf("%s: expected no diagnostics, got %v", q, diags)
+		}
+	}
+}
+
+func TestLintProblems(t *testing.T) {
+	cases := []struct {
+		query    string
+		severity Severity
+		message  string
+	}{
+		{"is:issue stat:open", Warning, `unknown qualifier "stat" (did you mean "state"?)`},
+		{"is:issue frobnicate:yes", Warning, `unknown qualifier "frobnicate"`},
+		{"is:issue (state:open", Error, "unclosed '('"},
+		{"is:issue state:open)", Error, "unmatched ')'"},
+		{"is:issue state:opened", Error, `invalid value "opened" for state`},
+		{"is:issue updated:>last-week", Error, `invalid value ">last-week" for updated`},
+		{"is:issue repo:kong", Error, `invalid value "kong" for repo`},
+		{"is:issue comments:many", Error, `invalid value "many" for comments`},
+		{"is:issue label:", Error, `qualifier "label" has no value`},
+		{"is:issue OR", Error, "OR has no term after it"},
+		{"is:issue (AND label:bug)", Error, "AND has no term before it"},
+		{"is:issue a or b", Warning, `"or" is searched as text`},
+		{"is:issue sort:updated sort:created", Warning, "multiple sort qualifiers"},
+		{"state:open", Warning, "add is:issue or is:pr"},
+		{"-is:pr state:open", Warning, "add is:issue or is:pr"},
+	}
+
+	for _, tc := range cases {
+		diags := Lint(tc.query)
+		found := false
+		for _, d := range diags {
+			if d.Severity == tc.severity && strings.Contains(d.Message, tc.message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s: expected %s %q, got %v", tc.query, tc.severity, tc.message, diags)
+		}
+	}
+}
+
+func TestLintEmptyQuery(t *testing.T) {
+	if diags := Lint("  "); diags != nil {
+		t.Fatalf("expected no diagnostics for empty query, got %v", diags)
+	}
+}
@@ -0,0 +1,143 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// validator checks a qualifier value, returning a description of the
+// problem or an empty string when the value is acceptable.
+type validator func(value string) string
+
+var (
+	dateValue    = `(\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}(:\d{2})?(Z|[+-]\d{2}:?\d{2})?)?|@today([+-]\d+[dwmy]?)?)`
+	datePattern  = regexp.MustCompile(`^((>=|<=|>|<)?` + dateValue + `|(` + dateValue + `|\*)\.\.(` + dateValue + `|\*))$`)
+	numPattern   = regexp.MustCompile(`^((>=|<=|>|<)?\d+|(\d+|\*)\.\.(\d+|\*))$`)
+	repoPattern  = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	sortPattern  = regexp.MustCompile(`^(created|updated|comments|interactions|reactions(-(\+1|-1|smile|tada|heart|thinking_face|rocket|eyes))?|author-date|committer-date)(-(asc|desc))?$`)
+	emptyCheck   = validator(func(string) string { return "" })
+	boolValues   = oneOf("true", "false")
+	dateValues   = matches(datePattern, "a date like 2026-01-31, >=2026-01-31, 2026-01-01..2026-01-31 or @today-7d")
+	numberValues = matches(numPattern, "a number like 5, >10 or 1..5")
+)
+
+// qualifiers lists the issue and pull request search qualifiers GitHub
+// understands, with a check for each one's value.
+var qualifiers = map[string]validator{
+	"is":                    oneOf("open", "closed", "issue", "pr", "merged", "unmerged", "public", "private", "locked", "unlocked", "draft", "queued", "archived", "blocked", "blocking"),
+	"type":                  emptyCheck,
+	"state":                 oneOf("open", "closed"),
+	"reason":                emptyCheck,
+	"in":                    listOf("title", "body", "comments"),
+	"author":                emptyCheck,
+	"assignee":              emptyCheck,
+	"mentions":              emptyCheck,
+	"commenter":             emptyCheck,
+	"involves":              emptyCheck,
+	"team":                  emptyCheck,
+	"team-review-requested": emptyCheck,
+	"review-requested":      emptyCheck,
+	"user-review-requested": emptyCheck,
+	"reviewed-by":           emptyCheck,
+	"label":                 emptyCheck,
+	"milestone":             emptyCheck,
+	"project":               emptyCheck,
+	"repo":                  matches(repoPattern, "owner/name"),
+	"org":                   emptyCheck,
+	"user":                  emptyCheck,
+	"language":              emptyCheck,
+	"comments":              numberValues,
+	"interactions":          numberValues,
+	"reactions":             numberValues,
+	"created":               dateValues,
+	"updated":               dateValues,
+	"closed":                dateValues,
+	"merged":                dateValues,
+	"no":                    oneOf("label", "milestone", "assignee", "project", "type", "parent-issue", "sub-issue"),
+	"has":                   oneOf("label", "milestone", "assignee", "project", "type", "parent-issue", "sub-issue"),
+	"head":                  emptyCheck,
+	"base":                  emptyCheck,
+	"status":                oneOf("pending", "success", "failure"),
+	"review":                oneOf("none", "required", "approved", "changes_requested"),
+	"draft":                 boolValues,
+	"archived":              boolValues,
+	"linked":                oneOf("pr", "issue"),
+	"sort":                  matches(sortPattern, "a sort like updated-desc"),
+	"sha":                   emptyCheck,
+	"parent-issue":          emptyCheck,
+}
+
+// KnownQualifier reports whether GitHub recognises the qualifier name.
+func KnownQualifier(name string) bool {
+	_, ok := qualifiers[strings.ToLower(name)]
+	return ok
+}
+
+func oneOf(values ...string) validator {
+	return func(v string) string {
+		for _, allowed := range values {
+			if strings.EqualFold(v, allowed) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("expected one of %s", strings.Join(values, ", "))
+	}
+}
+
+func listOf(values ...string) validator {
+	check := oneOf(values...)
+	return func(v string) string {
+		for _, part := range strings.Split(v, ",") {
+			if msg := check(part); msg != "" {
+				return msg
+			}
+		}
+		return ""
+	}
+}
+
+func matches(pattern *regexp.Regexp, want string) validator {
+	return func(v string) string {
+		if pattern.MatchString(v) {
+			return ""
+		}
+		return "expected " + want
+	}
+}
+
+// suggest returns the known qualifier closest to name, if any is within a
+// small edit distance.
+func suggest(name string) string {
+	best, bestDist := "", 3
+	for known := range qualifiers {
+		d := editDistance(name, known)
+		if d >= len(name) {
+			continue
+		}
+		if d < bestDist || (d == bestDist && best != "" && known < best) {
+			best, bestDist = known, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,147 @@
+// Package query tokenizes and lints GitHub issue and pull request search
+// queries.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind identifies the type of a token.
+type Kind int
+
+const (
+	// Term is a free-text search term, quoted or not.
+	Term Kind = iota
+	// Qualifier is a key:value filter such as state:open.
+	Qualifier
+	// And is the AND boolean operator.
+	And
+	// Or is the OR boolean operator.
+	Or
+	// Not is the NOT keyword.
+	Not
+	// LParen opens a group.
+	LParen
+	// RParen closes a group.
+	RParen
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Term:
+		return "term"
+	case Qualifier:
+		return "qualifier"
+	case And:
+		return "AND"
+	case Or:
+		return "OR"
+	case Not:
+		return "NOT"
+	case LParen:
+		return "("
+	case RParen:
+		return ")"
+	default:
+		return "unknown"
+	}
+}
+
+// Token is a single element of a search query.
+type Token struct {
+	Kind    Kind
+	Text    string // raw text as written, including any leading '-'
+	Pos     int    // byte offset in the query
+	Negated bool   // prefixed with '-'
+	Key     string // qualifier name, lower-cased
+	Value   string // qualifier value with surrounding quotes removed
+}
+
+// IsOperator reports whether the token is AND, OR or NOT.
+func (t Token) IsOperator() bool {
+	return t.Kind == And || t.Kind == Or || t.Kind == Not
+}
+
+// SyntaxError is a tokenizing error at a byte offset in the query.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+var qualifierKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// Tokenize splits a query into tokens. It only fails on unterminated quotes;
+// structural problems such as unbalanced parentheses are reported by Lint.
+func Tokenize(q string) ([]Token, error) {
+	var tokens []Token
+	for i := 0; i < len(q); {
+		c := q[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, Token{Kind: LParen, Text: "(", Pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, Token{Kind: RParen, Text: ")", Pos: i})
+			i++
+		default:
+			start := i
+			for i < len(q) && !strings.ContainsRune(" \t\r\n()", rune(q[i])) {
+				if q[i] == '"' {
+					end := strings.IndexByte(q[i+1:], '"')
+					if end < 0 {
+						return nil, &SyntaxError{Pos: i, Msg: "unterminated quoted string"}
+					}
+					i += end + 2
+					continue
+				}
+				i++
+			}
+			tokens = append(tokens, classify(q[start:i], start))
+		}
+	}
+	return tokens, nil
+}
+
+func classify(word string, pos int) Token {
+	tok := Token{Kind: Term, Text: word, Pos: pos}
+	switch word {
+	case "AND":
+		tok.Kind = And
+		return tok
+	case "OR":
+		tok.Kind = Or
+		return tok
+	case "NOT":
+		tok.Kind = Not
+		return tok
+	}
+
+	body := word
+	if strings.HasPrefix(body, "-") && len(body) > 1 {
+		tok.Negated = true
+		body = body[1:]
+	}
+
+	key, value, ok := strings.Cut(body, ":")
+	if ok && qualifierKeyPattern.MatchString(key) {
+		tok.Kind = Qualifier
+		tok.Key = strings.ToLower(key)
+		tok.Value = unquote(value)
+	}
+	return tok
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+package query
+
+import "testing"
+
+func TestTokenize(t *testing.T) {
+	tokens, err := Tokenize(`is:pr -author:app/renovate label:"good first issue" (repo:a/b OR repo:c/d) NOT flaky "exact phrase"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		kind    Kind
+		text    string
+		key     string
+		value   string
+		negated bool
+	}{
+		{Qualifier, "is:pr", "is", "pr", false},
+		{Qualifier, "-author:app/renovate", "author", "app/renovate", true},
+		{Qualifier, `label:"good first issue"`, "label", "good first issue", false},
+		{LParen, "(", "", "", false},
+		{Qualifier, "repo:a/b", "repo", "a/b", false},
+		{Or, "OR", "", "", false},
+		{Qualifier, "repo:c/d", "repo", "c/d", false},
+		{RParen, ")", "", "", false},
+		{Not, "NOT", "", "", false},
+		{Term, "flaky", "", "", false},
+		{Term, `"exact phrase"`, "", "", false},
+	}
+
+	if len(tokens) != len(want) {
+		t.Fatalf("expected %d tokens, got %d: %+v", len(want), len(tokens), tokens)
+	}
+	for i, w := range want {
+		got := tokens[i]
+		if got.Kind != w.kind || got.Text != w.text || got.Key != w.key || got.Value != w.value || got.Negated != w.negated {
+			t.Fatalf("token %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if tokens[2].Pos != 27 {
+		t.Fatalf("expected position 27, got %d", tokens[2].Pos)
+	}
+}
+
+func TestTokenizeUnterminatedQuote(t *testing.T) {
+	_, err := Tokenize(`is:pr label:"oops`)
+	if err == nil || err.Error() != "column 13: unterminated quoted string" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
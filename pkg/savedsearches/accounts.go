@@ -0,0 +1,180 @@
+package savedsearches
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	gh "github.com/cli/go-gh/v2"
+	"github.com/cli/go-gh/v2/pkg/auth"
+)
+
+// DefaultAccount is the name --account uses for searches with no account.
+const DefaultAccount = "default"
+
+// AccountDefinition is a named GitHub account searches can be synced to.
+// Token and Cookie are secret sources: env:NAME, file:PATH, encrypted:NAME or
+// keyring:NAME, and for the token also gh, the default, which asks gh for
+// the token of User on Host. Cookie may also be cookies:PATH for a
+// cookies.txt file; without one, the cookie saved by login is used.
+type AccountDefinition struct {
+	Host   string `yaml:"host,omitempty"`
+	User   string `yaml:"user,omitempty"`
+	Token  string `yaml:"token,omitempty"`
+	Cookie string `yaml:"cookie,omitempty"`
+}
+
+// EntryAccounts returns the account of every entry in cfg.Searches. An
+// entry without its own account takes the one of the section it sits in;
+// "" is the default account.
+func (cfg Config) EntryAccounts() []string {
+	out := make([]string, len(cfg.Searches))
+	section := ""
+	for i, search := range cfg.Searches {
+		if isSectionHeader(search) {
+			section = search.Account
+		}
+		out[i] = search.Account
+		if out[i] == "" {
+			out[i] = section
+		}
+	}
+	return out
+}
+
+// UsedAccounts returns the accounts the config's entries belong to, in
+// order of first use. "" is the default account.
+func (cfg Config) UsedAccounts() []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, account := range cfg.EntryAccounts() {
+		if !seen[account] {
+			seen[account] = true
+			out = append(out, account)
+		}
+	}
+	return out
+}
+
+// LookupAccount returns the named account. "" and DefaultAccount are the
+// default account, which has no definition.
+func (cfg Config) LookupAccount(name string) (AccountDefinition, error) {
+	if name == "" || name == DefaultAccount {
+		return AccountDefinition{}, nil
+	}
+	account, ok := cfg.Accounts[name]
+	if !ok {
+		return AccountDefinition{}, fmt.Errorf("unknown account %q (want one of %s)", name, strings.Join(cfg.accountNames(), ", "))
+	}
+	return account, nil
+}
+
+// accountNames lists the valid --account values.
+func (cfg Config) accountNames() []string {
+	names := make([]string, 0, len(cfg.Accounts)+1)
+	for name := range cfg.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultAccount}, names...)
+}
+
+// NewAccountClient builds a client for the named account. host is used when
+// the account doesn't set its own.
+func NewAccountClient(ctx context.Context, name string, account AccountDefinition, host string) (*GraphQLClient, error) {
+	if account.Host != "" {
+		host = account.Host
+	}
+	host = NormalizeHost(host)
+	if host == "" {
+		host = defaultHost
+	}
+
+	token, err := accountToken(ctx, account, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var cookie string
+	if account.Cookie != "" {
+		cookie, err = ReadCookie(account.Cookie, host)
+		if err != nil {
+			return nil, fmt.Errorf("read cookie: %w", err)
+		}
+	} else if cookie, err = storedCookie(host, name); err != nil {
+		return nil, err
+	}
+
+	return newGraphQLClient(host, token, cookie), nil
+}
+
+// accountToken resolves the account's token source.
+func accountToken(ctx context.Context, account AccountDefinition, host string) (string, error) {
+	if account.Token != "" && account.Token != "gh" {
+		token, err := ReadSecret(account.Token)
+		if err != nil {
+			return "", fmt.Errorf("read token: %w", err)
+		}
+		return token, nil
+	}
+
+	if account.User == "" {
+		token, _ := auth.TokenForHost(host)
+		if token == "" {
+			return "", fmt.Errorf("no GitHub token available for %s; run gh auth login --hostname %s", host, host)
+		}
+		return token, nil
+	}
+
+	stdout, stderr, err := gh.ExecContext(ctx, "auth", "token", "--hostname", host, "--user", account.User)
+	if err != nil {
+		return "", fmt.Errorf("gh auth token for %s on %s: %s", account.User, host, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ReadSecret reads a secret from its source: env:NAME for an environment
+// variable, file:PATH for the trimmed contents of a file, encrypted:NAME for
+// an entry in the default EncryptedStore, or keyring:NAME for one in the OS
+// keyring.
+func ReadSecret(source string) (string, error) {
+	kind, ref, _ := strings.Cut(source, ":")
+	var value string
+	switch kind {
+	case "env":
+		value = os.Getenv(ref)
+		if value == "" {
+			return "", fmt.Errorf("environment variable %s is not set", ref)
+		}
+	case "file":
+		path, err := expandPath(ref)
+		if err != nil {
+			return "", err
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		value = strings.TrimSpace(string(raw))
+		if value == "" {
+			return "", fmt.Errorf("%s is empty", path)
+		}
+	case "encrypted":
+		store, err := DefaultEncryptedStore()
+		if err != nil {
+			return "", err
+		}
+		return store.Get(ref)
+	case "keyring":
+		store, err := OpenSecretStore("keyring")
+		if err != nil {
+			return "", err
+		}
+		return store.Get(ref)
+	default:
+		return "", fmt.Errorf("unknown secret source %q (want env:NAME, file:PATH, encrypted:NAME or keyring:NAME)", source)
+	}
+	return value, nil
+}
@@ -0,0 +1,162 @@
+package savedsearches
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEntryAccounts(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{
+		{Name: "Mine", Query: "is:issue"},
+		{Section: "Work", Account: "work"},
+		{Name: "Reviews", Query: "is:pr"},
+		{Name: "Side", Query: "is:pr", Account: "personal"},
+		{Section: "Other"},
+		{Name: "Later", Query: "is:issue"},
+	}}
+
+	want := []string{"", "work", "work", "personal", "", ""}
+	if got := cfg.EntryAccounts(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected accounts: %q", got)
+	}
+	if got := cfg.UsedAccounts(); !reflect.DeepEqual(got, []string{"", "work", "personal"}) {
+		t.Fatalf("unexpected used accounts: %q", got)
+	}
+}
+
+func TestLookupAccount(t *testing.T) {
+	cfg := Config{Accounts: map[string]AccountDefinition{"work": {Host: "ghe.example.com"}}}
+
+	if account, err := cfg.LookupAccount("work"); err != nil || account.Host != "ghe.example.com" {
+		t.Fatalf("unexpected account: %+v %v", account, err)
+	}
+	if _, err := cfg.LookupAccount(DefaultAccount); err != nil {
+		t.Fatalf("expected the default account, got %v", err)
+	}
+	if _, err := cfg.LookupAccount("play"); err == nil || !strings.Contains(err.Error(), `unknown account "play" (want one of default, work)`) {
+		t.Fatalf("expected unknown account error, got %v", err)
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	t.Setenv("WORK_TOKEN", "from-env")
+	path := filepath.Join(t.TempDir(), "cookie")
+	if err := os.WriteFile(path, []byte("user_session=abc\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+
+	if got, err := ReadSecret("env:WORK_TOKEN"); err != nil || got != "from-env" {
+		t.Fatalf("unexpected env secret: %q %v", got, err)
+	}
+	if got, err := ReadSecret("file:" + path); err != nil || got != "user_session=abc" {
+		t.Fatalf("unexpected file secret: %q %v", got, err)
+	}
+	if _, err := ReadSecret("env:MISSING_TOKEN"); err == nil {
+		t.Fatalf("expected error for unset variable")
+	}
+	if _, err := ReadSecret("hunter2"); err == nil || !strings.Contains(err.Error(), "unknown secret source") {
+		t.Fatalf("expected unknown source error, got %v", err)
+	}
+}
+
+func TestNewAccountClient(t *testing.T) {
+	t.Setenv("WORK_TOKEN", "work-token")
+	t.Setenv("WORK_COOKIE", "user_session=work")
+
+	account := AccountDefinition{Host: "ghe.example.com", Token: "env:WORK_TOKEN", Cookie: "env:WORK_COOKIE"}
+	client, err := NewAccountClient(context.Background(), "work", account, "github.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.token != "work-token" || client.cookie != "user_session=work" || client.Host() != "ghe.example.com" {
+		t.Fatalf("unexpected client: %+v", client)
+	}
+}
+
+func TestSyncerOnlyTouchesItsAccount(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	cfgYAML := `
+accounts:
+  work:
+    token: env:WORK_TOKEN
+searches:
+  - name: Mine
+    query: is:issue author:@me
+  - section: Work
+    account: work
+  - name: Reviews
+    query: is:pr review-requested:@me
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	personal := &stubClient{nextID: "SSC_personal"}
+	if err := newTestSyncer(personal).Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync default account: %v", err)
+	}
+	work := &stubClient{nextID: "SSC_work"}
+	syncer := newTestSyncer(work, WithAccount("work"))
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync work account: %v", err)
+	}
+
+	if len(personal.created) != 1 || personal.created[0].Name != "Mine" {
+		t.Fatalf("unexpected default account creates: %+v", personal.created)
+	}
+	if len(work.created) != 2 || work.created[0].Name != "== Work ==" || work.created[1].Name != "Reviews" {
+		t.Fatalf("unexpected work account creates: %+v", work.created)
+	}
+	if results := syncer.Results(); len(results) != 2 || results[1].Account != "work" {
+		t.Fatalf("expected results tagged with the account, got %+v", results)
+	}
+
+	cfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("reload cfg: %v", err)
+	}
+	ids := []string{cfg.Searches[0].ID, cfg.Searches[1].ID, cfg.Searches[2].ID}
+	if !reflect.DeepEqual(ids, []string{"SSC_personal", "SSC_work", "SSC_work"}) {
+		t.Fatalf("unexpected ids: %q", ids)
+	}
+
+	if err := newTestSyncer(work, WithAccount("play")).Sync(context.Background(), cfgPath); err == nil || !strings.Contains(err.Error(), "unknown account") {
+		t.Fatalf("expected unknown account error, got %v", err)
+	}
+}
+
+func TestSyncerDropsIDsOfAnotherAccount(t *testing.T) {
+	cfg := Config{
+		Accounts: map[string]AccountDefinition{"work": {Token: "env:WORK_TOKEN"}},
+		Searches: []SearchDefinition{
+			{Name: "Moved", Query: "is:pr", Account: "work", ID: "SSC_default"},
+			{Name: "Stayed", Query: "is:issue", Account: "work", IDAccount: "work", ID: "SSC_work"},
+		},
+	}
+
+	work := &stubClient{nextID: "SSC_new"}
+	out, updated, err := newTestSyncer(work, WithAccount("work")).SyncConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if len(work.created) != 1 || work.created[0].Name != "Moved" || len(work.updated) != 1 || work.updated[0].Name != "Stayed" {
+		t.Fatalf("expected the moved search to be created, got created %+v updated %+v", work.created, work.updated)
+	}
+	if !updated || out.Searches[0].ID != "SSC_new" || out.Searches[0].IDAccount != "work" {
+		t.Fatalf("expected the new ID recorded for the work account, got %+v", out.Searches[0])
+	}
+}
+
+func TestValidateConfigRejectsDefaultAccount(t *testing.T) {
+	cfg := Config{Accounts: map[string]AccountDefinition{DefaultAccount: {Host: "ghe.example.com"}}}
+
+	problems := ValidateConfig(cfg, nil)
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), `"default" is reserved`) {
+		t.Fatalf("expected the default account to be rejected, got %v", problems)
+	}
+}
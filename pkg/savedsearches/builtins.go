@@ -0,0 +1,91 @@
+package savedsearches
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// viewerVarNames are built-in vars that need a lookup of the authenticated user.
+var viewerVarNames = []string{"login", "orgs"}
+
+// ResolveBuiltins returns the vars the tool provides to every template:
+// now and today from clock, host, plus login and orgs when withViewer is
+// set and the client can look up the authenticated user.
+func ResolveBuiltins(ctx context.Context, client Client, clock Clock, withViewer bool) (map[string]any, error) {
+	vars, err := viewerBuiltins(ctx, client, withViewer)
+	if err != nil {
+		return nil, err
+	}
+	return MergeVars(timeBuiltins(clock.Now()), vars), nil
+}
+
+// timeBuiltins returns the built-in vars that change with the time. They are
+// cheap, so callers resolve them for every render rather than caching them.
+func timeBuiltins(now time.Time) map[string]any {
+	return map[string]any{
+		"now":   now.UTC().Format(time.RFC3339),
+		"today": now.Format(dateLayout),
+	}
+}
+
+// viewerBuiltins returns host, taken from the client when it implements
+// HostClient, plus login and orgs when withViewer is set and the client can
+// look up the authenticated user. They don't change during a run, so a
+// Syncer resolves them once.
+func viewerBuiltins(ctx context.Context, client Client, withViewer bool) (map[string]any, error) {
+	vars := map[string]any{"host": defaultHost}
+	if hc, ok := client.(HostClient); ok {
+		vars["host"] = hc.Host()
+	}
+
+	vc, ok := client.(ViewerClient)
+	if !withViewer || !ok {
+		return vars, nil
+	}
+
+	viewer, err := vc.Viewer(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("resolve built-in vars: %w", err)
+	}
+
+	orgs := make([]any, 0, len(viewer.Orgs))
+	for _, org := range viewer.Orgs {
+		orgs = append(orgs, org)
+	}
+	vars["login"] = viewer.Login
+	vars["orgs"] = orgs
+
+	return vars, nil
+}
+
+// UsesVars reports whether any query, template or name in the config
+// references one of the given vars.
+func (cfg Config) UsesVars(names ...string) bool {
+	var texts []string
+	for _, tpl := range cfg.Templates {
+		texts = append(texts, tpl.Query)
+	}
+	for _, def := range cfg.Searches {
+		texts = append(texts, def.Query, def.Name)
+	}
+
+	for _, text := range texts {
+		if !strings.Contains(text, "{{") {
+			continue
+		}
+		_, idents, err := translateTemplate(text, templateFuncs(realClock{}))
+		if err != nil {
+			continue
+		}
+		for _, ident := range idents {
+			for _, name := range names {
+				if ident == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package savedsearches
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveBuiltins(t *testing.T) {
+	client := &stubClient{viewer: Viewer{Login: "alice", Orgs: []string{"Kong"}, Host: "github.com"}}
+	vars, err := ResolveBuiltins(context.Background(), client, newFakeClock(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if vars["login"] != "alice" || vars["today"] != "2026-10-16" || vars["now"] != "2026-10-16T09:00:00Z" || vars["host"] != "github.com" {
+		t.Fatalf("unexpected builtins: %+v", vars)
+	}
+	if orgs, ok := vars["orgs"].([]any); !ok || len(orgs) != 1 || orgs[0] != "Kong" {
+		t.Fatalf("unexpected orgs: %+v", vars["orgs"])
+	}
+
+	if _, err := ResolveBuiltins(context.Background(), client, newFakeClock(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.viewerCalls != 1 {
+		t.Fatalf("expected viewer lookup only when requested, got %d calls", client.viewerCalls)
+	}
+}
+
+func TestConfigUsesVars(t *testing.T) {
+	cfg := Config{
+		Templates: map[string]TemplateDefinition{
+			"mine": {Query: "{{ join(prefix(orgs, \"org:\"), \"OR\") }}"},
+		},
+	}
+	if !cfg.UsesVars("orgs") {
+		t.Fatalf("expected orgs to be detected in call arguments")
+	}
+	if cfg.UsesVars("login") {
+		t.Fatalf("did not expect login to be detected")
+	}
+
+	cfg.Searches = []SearchDefinition{{Name: "Mine", Query: "assignee:{{ login }}"}}
+	if !cfg.UsesVars("login") {
+		t.Fatalf("expected login to be detected in plain queries")
+	}
+}
+
+func TestSyncerResolvesViewerOnce(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+searches:
+  - name: Mine
+    query: "assignee:{{ login }} {{ anyOf(prefix(orgs, \"org:\")) }}"
+  - name: Authored
+    query: "author:{{ login }}"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &stubClient{viewer: Viewer{Login: "alice", Orgs: []string{"Kong", "mheap"}}}
+	syncer := newTestSyncer(client)
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if client.viewerCalls != 1 {
+		t.Fatalf("expected a single viewer lookup, got %d", client.viewerCalls)
+	}
+	if client.created[0].Query != "assignee:alice (org:Kong OR org:mheap)" || client.created[1].Query != "author:alice" {
+		t.Fatalf("unexpected queries: %+v", client.created)
+	}
+}
+
+func TestSyncerResolvesTimeBuiltinsEachSync(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{{Name: "Recent", Query: "updated:>={{ today }}"}}}
+	client := &stubClient{nextID: "SSC_1"}
+	clock := newFakeClock()
+	syncer := NewSyncer(client, WithClock(clock))
+
+	cfg, _, err := syncer.SyncConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	clock.Advance(48 * time.Hour)
+	if _, _, err := syncer.SyncConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+
+	if len(client.created) != 1 || client.created[0].Query != "updated:>=2026-10-16" {
+		t.Fatalf("unexpected creates: %+v", client.created)
+	}
+	if len(client.updated) != 1 || client.updated[0].Query != "updated:>=2026-10-18" {
+		t.Fatalf("expected the second sync to use the clock's new date, got %+v", client.updated)
+	}
+}
+
+type hostClient struct {
+	stubClient
+	host string
+}
+
+func (h *hostClient) Host() string { return h.host }
+
+func TestSyncerTakesHostFromClient(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{{Name: "Here", Query: "repo:{{ host }}/a/b"}}}
+
+	client := &hostClient{host: "ghe.example.com"}
+	if _, _, err := newTestSyncer(client).SyncConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if client.viewerCalls != 0 {
+		t.Fatalf("expected no viewer lookup for host, got %d", client.viewerCalls)
+	}
+	if len(client.created) != 1 || client.created[0].Query != "repo:ghe.example.com/a/b" {
+		t.Fatalf("expected the client's host, got %+v", client.created)
+	}
+}
@@ -7,15 +7,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/cli/go-gh/v2/pkg/auth"
 )
 
 const (
-	defaultEndpoint = "https://github.com/_graphql"
+	defaultHost        = "github.com"
+	defaultEndpoint    = "https://github.com/_graphql"
+	defaultAPIEndpoint = "https://api.github.com/graphql"
 
 	createPersistedID = "c06c5627e09922bd28c6d34ff91d0530"
 	updatePersistedID = "379dbe4cf68c3485e48df2f699f5ae75"
@@ -36,26 +43,114 @@ type Client interface {
 	DeleteSavedSearch(ctx context.Context, id string) error
 }
 
-// GraphQLClient performs GraphQL requests against github.com.
+// SavedSearch is a saved search as it currently exists on GitHub.
+type SavedSearch struct {
+	ID    string
+	Name  string
+	Query string
+}
+
+// SavedSearchLister is implemented by clients that can read the current saved
+// searches. The syncer uses it to skip updates that wouldn't change anything.
+// ListSavedSearches returns ErrListUnavailable when the client has no way to
+// list them, which callers treat as "unknown" rather than as a failure.
+type SavedSearchLister interface {
+	ListSavedSearches(ctx context.Context) ([]SavedSearch, error)
+}
+
+// ErrListUnavailable is returned by ListSavedSearches when no persisted query
+// for listing is known.
+var ErrListUnavailable = errors.New("no persisted query for listing saved searches; run gh saved-issues discover or set persisted_queries.list")
+
+// Viewer describes the authenticated user.
+type Viewer struct {
+	Login string
+	Orgs  []string
+	Host  string
+}
+
+// ViewerClient is implemented by clients that can look up the authenticated
+// user. It is optional; the syncer only uses it for built-in template vars.
+type ViewerClient interface {
+	Viewer(ctx context.Context) (Viewer, error)
+}
+
+// HostClient is implemented by clients that know the GitHub host they talk
+// to. The syncer uses it for the host built-in template var.
+type HostClient interface {
+	Host() string
+}
+
+// GraphQLClient performs GraphQL requests against github.com or a GitHub
+// Enterprise host.
 type GraphQLClient struct {
-	httpClient *http.Client
-	endpoint   string
-	token      string
-	cookie     string
+	httpClient  *http.Client
+	endpoint    string
+	apiEndpoint string
+	token       string
+	cookie      string
+	queries     PersistedQueries
+	logger      *slog.Logger
+}
+
+// SetLogger logs request and response metadata for every GraphQL call at
+// debug level. Tokens and cookie values are redacted; bodies are not logged.
+func (c *GraphQLClient) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// SetPersistedQueries overrides the persisted query IDs the client sends.
+// Empty fields keep the built-in IDs.
+func (c *GraphQLClient) SetPersistedQueries(queries PersistedQueries) {
+	c.queries = queries
 }
 
-// NewGraphQLClient builds a client using GH authentication.
+// NewGraphQLClient builds a client using GH authentication. The host is taken
+// from endpoint, which defaults to github.com's.
 func NewGraphQLClient(ctx context.Context, endpoint string) (*GraphQLClient, error) {
-	token := os.Getenv("GH_TOKEN")
-	if token == "" {
-		token = os.Getenv("GITHUB_TOKEN")
+	if endpoint == "" {
+		return NewGraphQLClientForHost(ctx, defaultHost)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+
+	client, err := NewGraphQLClientForHost(ctx, u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	client.endpoint = endpoint
+	return client, nil
+}
+
+// NewGraphQLClientForHost builds a client for a GitHub host, using the token
+// gh would use for it. Enterprise hosts read GH_ENTERPRISE_TOKEN rather than
+// GH_TOKEN. The session cookie comes from GH_COOKIE, GH_COOKIE_FILE or, when
+// neither is set, the secret store login saved it in.
+func NewGraphQLClientForHost(ctx context.Context, host string) (*GraphQLClient, error) {
+	host = NormalizeHost(host)
+	if host == "" {
+		host = defaultHost
+	}
+
+	var token string
+	if !auth.IsEnterprise(host) {
+		token = os.Getenv("GH_TOKEN")
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
 	}
 
 	if token == "" {
-		token, _ = auth.TokenForHost("github.com")
+		token, _ = auth.TokenForHost(host)
 	}
 
 	if token == "" {
+		if auth.IsEnterprise(host) {
+			return nil, fmt.Errorf("no GitHub token available for %s; set GH_ENTERPRISE_TOKEN or gh auth login --hostname %s", host, host)
+		}
 		return nil, errors.New("no GitHub token available; set GH_TOKEN or gh auth login")
 	}
 
@@ -64,16 +159,57 @@ func NewGraphQLClient(ctx context.Context, endpoint string) (*GraphQLClient, err
 		cookie = os.Getenv("GITHUB_COOKIE")
 	}
 
-	if endpoint == "" {
-		endpoint = defaultEndpoint
+	if jar := os.Getenv("GH_COOKIE_FILE"); cookie == "" && jar != "" {
+		var err error
+		cookie, err = ReadCookieJar(jar, host)
+		if err != nil {
+			return nil, fmt.Errorf("read cookie: %w", err)
+		}
 	}
 
+	if cookie == "" {
+		var err error
+		if cookie, err = storedCookie(host, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return newGraphQLClient(host, token, cookie), nil
+}
+
+func newGraphQLClient(host, token, cookie string) *GraphQLClient {
+	endpoint, apiEndpoint := Endpoints(host)
 	return &GraphQLClient{
-		httpClient: http.DefaultClient,
-		endpoint:   endpoint,
-		token:      token,
-		cookie:     cookie,
-	}, nil
+		httpClient:  http.DefaultClient,
+		endpoint:    endpoint,
+		apiEndpoint: apiEndpoint,
+		token:       token,
+		cookie:      cookie,
+	}
+}
+
+// Endpoints returns the web UI and public API GraphQL endpoints for a host.
+// Enterprise Server serves the API under /api; github.com and GHE.com
+// tenants serve it from an api. subdomain.
+func Endpoints(host string) (web, api string) {
+	host = NormalizeHost(host)
+	switch {
+	case host == "" || host == defaultHost:
+		return defaultEndpoint, defaultAPIEndpoint
+	case auth.IsEnterprise(host):
+		return "https://" + host + "/_graphql", "https://" + host + "/api/graphql"
+	default:
+		return "https://" + host + "/_graphql", "https://api." + host + "/graphql"
+	}
+}
+
+// NormalizeHost lowercases host and strips any scheme or trailing slash, so
+// "https://GHE.example.com/" and "ghe.example.com" are the same host.
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	return strings.TrimSuffix(host, "/")
 }
 
 // CreateSavedSearch creates a new shortcut and returns the id.
@@ -92,7 +228,7 @@ func (c *GraphQLClient) CreateSavedSearch(ctx context.Context, input SavedSearch
 		vars["input"].(map[string]any)["description"] = input.Description
 	}
 
-	data, err := c.graphQL(ctx, createPersistedID, vars)
+	data, err := c.persistedQuery(ctx, "create", vars)
 	if err != nil {
 		return "", err
 	}
@@ -119,7 +255,7 @@ func (c *GraphQLClient) UpdateSavedSearch(ctx context.Context, id string, input
 		},
 	}
 
-	_, err := c.graphQL(ctx, updatePersistedID, vars)
+	_, err := c.persistedQuery(ctx, "update", vars)
 	return err
 }
 
@@ -131,10 +267,110 @@ func (c *GraphQLClient) DeleteSavedSearch(ctx context.Context, id string) error
 		},
 	}
 
-	_, err := c.graphQL(ctx, deletePersistedID, vars)
+	_, err := c.persistedQuery(ctx, "delete", vars)
 	return err
 }
 
+// ListSavedSearches reads the dashboard's shortcuts with the list persisted
+// query. Unlike the other operations it has no built-in ID, since GitHub
+// loads the list as part of its pages; it has to be discovered or set.
+func (c *GraphQLClient) ListSavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	if c.queryID("list") == "" {
+		return nil, ErrListUnavailable
+	}
+
+	data, err := c.persistedQuery(ctx, "list", map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, ok := findShortcutNodes(data)
+	if !ok {
+		return nil, errors.New("list saved searches: shortcuts not found in response")
+	}
+
+	searches := make([]SavedSearch, 0, len(nodes))
+	for _, n := range nodes {
+		node, _ := n.(map[string]any)
+		id, _ := node["id"].(string)
+		if !strings.HasPrefix(id, "SSC_") {
+			continue
+		}
+		name, _ := node["name"].(string)
+		query, _ := node["query"].(string)
+		searches = append(searches, SavedSearch{ID: id, Name: name, Query: query})
+	}
+	return searches, nil
+}
+
+// findShortcutNodes looks for a shortcuts connection in a response,
+// wherever the query nests it (under viewer, dashboard and so on). Keys are
+// visited in sorted order so the same response always gives the same
+// connection.
+func findShortcutNodes(data any) ([]any, bool) {
+	m, ok := data.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if shortcuts, ok := m["shortcuts"].(map[string]any); ok {
+		if nodes, ok := shortcuts["nodes"].([]any); ok {
+			return nodes, true
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if nodes, ok := findShortcutNodes(m[key]); ok {
+			return nodes, true
+		}
+	}
+	return nil, false
+}
+
+const viewerQuery = `query { viewer { login organizations(first: 100) { nodes { login } } } }`
+
+// Viewer looks up the authenticated user and their organizations through the
+// public GraphQL API.
+func (c *GraphQLClient) Viewer(ctx context.Context) (Viewer, error) {
+	data, err := c.apiGraphQL(ctx, viewerQuery, nil)
+	if err != nil {
+		return Viewer{}, fmt.Errorf("query viewer: %w", err)
+	}
+
+	viewer := Viewer{Host: c.Host()}
+	v, _ := data["viewer"].(map[string]any)
+	viewer.Login, _ = v["login"].(string)
+	if viewer.Login == "" {
+		return Viewer{}, errors.New("query viewer: login not found in response")
+	}
+
+	orgs, _ := v["organizations"].(map[string]any)
+	nodes, _ := orgs["nodes"].([]any)
+	for _, n := range nodes {
+		node, _ := n.(map[string]any)
+		if login, _ := node["login"].(string); login != "" {
+			viewer.Orgs = append(viewer.Orgs, login)
+		}
+	}
+
+	return viewer, nil
+}
+
+// Host returns the GitHub host the client talks to.
+func (c *GraphQLClient) Host() string {
+	if u, err := url.Parse(c.endpoint); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return defaultHost
+}
+
+// origin returns the web origin the persisted queries must appear to come
+// from: the scheme and host of the endpoint.
+func (c *GraphQLClient) origin() string {
+	if u, err := url.Parse(c.endpoint); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host
+	}
+	return "https://" + defaultHost
+}
+
 type graphQLRequest struct {
 	Query     string         `json:"query"`
 	Variables map[string]any `json:"variables"`
@@ -147,57 +383,184 @@ type graphQLResponse struct {
 	} `json:"errors"`
 }
 
+// graphQL calls the web UI's persisted query endpoint.
 func (c *GraphQLClient) graphQL(ctx context.Context, query string, variables map[string]any) (map[string]any, error) {
+	return c.post(ctx, c.endpoint, query, variables, true)
+}
+
+// persistedQuery runs the persisted query for an operation. A query GitHub
+// no longer knows gives ErrPersistedQueryNotFound.
+func (c *GraphQLClient) persistedQuery(ctx context.Context, operation string, variables map[string]any) (map[string]any, error) {
+	id := c.queryID(operation)
+	data, err := c.graphQL(ctx, id, variables)
+	if isPersistedQueryNotFound(err) {
+		return nil, fmt.Errorf("%w: %s query %s (%v)", ErrPersistedQueryNotFound, operation, id, err)
+	}
+	return data, err
+}
+
+// queryID returns the persisted query ID the client uses for an operation.
+func (c *GraphQLClient) queryID(operation string) string {
+	return DefaultPersistedQueries().Merge(c.queries).ID(operation)
+}
+
+// apiGraphQL calls the public GraphQL API, which only needs the token.
+func (c *GraphQLClient) apiGraphQL(ctx context.Context, query string, variables map[string]any) (map[string]any, error) {
+	endpoint := c.apiEndpoint
+	if endpoint == "" {
+		endpoint = defaultAPIEndpoint
+	}
+	return c.post(ctx, endpoint, query, variables, false)
+}
+
+func (c *GraphQLClient) post(ctx context.Context, endpoint, query string, variables map[string]any, web bool) (map[string]any, error) {
+	data, _, err := c.send(ctx, endpoint, query, variables, web)
+	return data, err
+}
+
+// GraphQLError holds the errors a GraphQL response reported.
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	return "graphql error: " + strings.Join(e.Messages, "; ")
+}
+
+// send does the work for post, also returning the response headers.
+func (c *GraphQLClient) send(ctx context.Context, endpoint, query string, variables map[string]any, web bool) (map[string]any, http.Header, error) {
 	payload := graphQLRequest{Query: query, Variables: variables}
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("marshal graphql request: %w", err)
+		return nil, nil, fmt.Errorf("marshal graphql request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("build request: %w", err)
+		return nil, nil, fmt.Errorf("build request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.token)
-	if c.cookie != "" {
-		req.Header.Set("Cookie", c.cookie)
-	}
+	if web {
+		if c.cookie != "" {
+			req.Header.Set("Cookie", c.cookie)
+		}
 
-	req.Header.Set("github-verified-fetch", "true")
-	req.Header.Set("origin", "https://github.com")
+		req.Header.Set("github-verified-fetch", "true")
+		req.Header.Set("origin", c.origin())
+	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("post graphql: %w", err)
+		c.logRequest(req, len(body), nil, 0, time.Since(start), err)
+		return nil, nil, fmt.Errorf("post graphql: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	c.logRequest(req, len(body), resp, len(respBody), time.Since(start), err)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if web && sessionRejected(resp) {
+		if c.cookie == "" {
+			return nil, nil, fmt.Errorf("graphql status %d: no session cookie; run login or set GH_COOKIE", resp.StatusCode)
+		}
+		return nil, nil, fmt.Errorf("graphql status %d: %w", resp.StatusCode, ErrSessionExpired)
 	}
 
 	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("graphql status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+		return nil, nil, fmt.Errorf("graphql status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
 	}
 
 	var parsed graphQLResponse
 	if err := json.Unmarshal(respBody, &parsed); err != nil {
-		return nil, fmt.Errorf("parse graphql response: %w", err)
+		return nil, nil, fmt.Errorf("parse graphql response: %w", err)
 	}
 
 	if len(parsed.Errors) > 0 {
-		var messages []string
+		gqlErr := &GraphQLError{}
 		for _, e := range parsed.Errors {
-			messages = append(messages, e.Message)
+			gqlErr.Messages = append(gqlErr.Messages, e.Message)
 		}
-		return nil, fmt.Errorf("graphql error: %s", strings.Join(messages, "; "))
+		return nil, resp.Header, gqlErr
 	}
 
-	return parsed.Data, nil
+	return parsed.Data, resp.Header, nil
+}
+
+// sessionRejected reports whether a web endpoint response means the session
+// cookie was missing or no longer valid: an auth failure, or a redirect to
+// the login or session page. Server errors are never treated as expiry, even
+// when GitHub serves them as HTML.
+func sessionRejected(resp *http.Response) bool {
+	if resp.StatusCode >= 500 {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		switch resp.Request.URL.Path {
+		case "/login", "/session":
+			return true
+		}
+	}
+	return false
+}
+
+// logRequest logs one GraphQL round trip, if a logger is set.
+func (c *GraphQLClient) logRequest(req *http.Request, reqBytes int, resp *http.Response, respBytes int, elapsed time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.Any("headers", redactHeaders(req.Header)),
+		slog.Int("request_bytes", reqBytes),
+		slog.Duration("duration", elapsed),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.Int("response_bytes", respBytes),
+			slog.String("request_id", resp.Header.Get("X-Github-Request-Id")),
+		)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.logger.Debug("graphql request", attrs...)
+}
+
+// redactHeaders flattens headers for logging, hiding credentials. Cookie
+// names are kept so it's visible which cookies were sent.
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization":
+			scheme, _, _ := strings.Cut(value, " ")
+			value = scheme + " [REDACTED]"
+		case "Cookie":
+			var cookies []string
+			for _, part := range strings.Split(value, ";") {
+				if cookieName, _, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+					cookies = append(cookies, cookieName+"=[REDACTED]")
+				}
+			}
+			value = strings.Join(cookies, "; ")
+		}
+		out[name] = value
+	}
+	return out
 }
 
 // findShortcutID reads the saved search id from the known response shape.
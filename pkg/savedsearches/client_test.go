@@ -1,10 +1,15 @@
 package savedsearches
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -12,18 +17,18 @@ func TestCreateSavedSearch(t *testing.T) {
 	var received graphQLRequest
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Authorization") == "" {
-			t.Fatalf("expected auth header")
+			t.Errorf("expected auth header")
 		}
 		if r.Header.Get("Cookie") != "a=b" {
-			t.Fatalf("expected cookie header")
+			t.Errorf("expected cookie header")
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
-			t.Fatalf("decode: %v", err)
+			t.Errorf("decode: %v", err)
 		}
 
 		if received.Query != createPersistedID {
-			t.Fatalf("expected persisted id %s, got %s", createPersistedID, received.Query)
+			t.Errorf("expected persisted id %s, got %s", createPersistedID, received.Query)
 		}
 
 		resp := graphQLResponse{
@@ -74,10 +79,10 @@ func TestUpdateSavedSearch(t *testing.T) {
 		var req graphQLRequest
 		_ = json.NewDecoder(r.Body).Decode(&req)
 		if req.Query != updatePersistedID {
-			t.Fatalf("expected %s, got %s", updatePersistedID, req.Query)
+			t.Errorf("expected %s, got %s", updatePersistedID, req.Query)
 		}
 		if r.Header.Get("Cookie") != "" {
-			t.Fatalf("unexpected cookie header")
+			t.Errorf("unexpected cookie header")
 		}
 		w.Write([]byte(`{"data":{"ok":true}}`))
 	}))
@@ -103,7 +108,7 @@ func TestDeleteSavedSearch(t *testing.T) {
 		var req graphQLRequest
 		_ = json.NewDecoder(r.Body).Decode(&req)
 		if req.Query != deletePersistedID {
-			t.Fatalf("expected %s, got %s", deletePersistedID, req.Query)
+			t.Errorf("expected %s, got %s", deletePersistedID, req.Query)
 		}
 		w.Write([]byte(`{"data":{"ok":true}}`))
 	}))
@@ -120,6 +125,58 @@ func TestDeleteSavedSearch(t *testing.T) {
 	}
 }
 
+func TestListSavedSearches(t *testing.T) {
+	var gotQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		gotQuery = req.Query
+		w.Write([]byte(`{"data":{"viewer":{"dashboard":{"shortcuts":{"nodes":[
+			{"id":"SSC_team","name":"== Team ==","query":""},
+			{"id":"SSC_mine","name":"Mine","query":"is:issue assignee:@me"}
+		]}}}}}`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), endpoint: ts.URL, token: "token", cookie: "a=b"}
+	if _, err := client.ListSavedSearches(context.Background()); !errors.Is(err, ErrListUnavailable) {
+		t.Fatalf("expected list to be unavailable without an ID, got %v", err)
+	}
+
+	client.SetPersistedQueries(PersistedQueries{List: "list-id"})
+	searches, err := client.ListSavedSearches(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != "list-id" {
+		t.Fatalf("expected the list query ID, got %q", gotQuery)
+	}
+	want := []SavedSearch{
+		{ID: "SSC_team", Name: "== Team =="},
+		{ID: "SSC_mine", Name: "Mine", Query: "is:issue assignee:@me"},
+	}
+	if !reflect.DeepEqual(searches, want) {
+		t.Fatalf("unexpected searches: %+v", searches)
+	}
+}
+
+func TestFindShortcutNodesIsDeterministic(t *testing.T) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"viewer": {"dashboard": {"shortcuts": {"nodes": [{"id": "SSC_viewer"}]}}},
+		"dashboard": {"shortcuts": {"nodes": [{"id": "SSC_dashboard"}]}}
+	}`), &data); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		nodes, ok := findShortcutNodes(data)
+		if !ok || nodes[0].(map[string]any)["id"] != "SSC_dashboard" {
+			t.Fatalf("expected the dashboard connection every time, got %v", nodes)
+		}
+	}
+}
+
 func TestGraphQLError(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{"errors":[{"message":"boom"}]}`))
@@ -137,3 +194,212 @@ func TestGraphQLError(t *testing.T) {
 		t.Fatalf("expected error")
 	}
 }
+
+func TestGraphQLSessionExpired(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login?return_to=%2F", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html>Sign in to GitHub</html>"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	for _, path := range []string{"/forbidden", "/redirect"} {
+		client := &GraphQLClient{
+			httpClient: ts.Client(),
+			endpoint:   ts.URL + path,
+			token:      "token",
+			cookie:     "user_session=stale",
+		}
+		_, err := client.graphQL(context.Background(), "ignored", nil)
+		if !errors.Is(err, ErrSessionExpired) || !strings.Contains(err.Error(), "session expired, refresh cookie") {
+			t.Fatalf("%s: expected expired session, got %v", path, err)
+		}
+	}
+
+	client := &GraphQLClient{httpClient: ts.Client(), endpoint: ts.URL + "/forbidden", token: "token"}
+	if _, err := client.graphQL(context.Background(), "ignored", nil); err == nil || !strings.Contains(err.Error(), "no session cookie") {
+		t.Fatalf("expected missing cookie error, got %v", err)
+	}
+}
+
+func TestGraphQLServerErrorIsNotSessionExpiry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>Unicorn!</html>"))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), endpoint: ts.URL, token: "token", cookie: "user_session=ok"}
+	_, err := client.graphQL(context.Background(), "ignored", nil)
+	if err == nil || errors.Is(err, ErrSessionExpired) || !strings.Contains(err.Error(), "graphql status 502") {
+		t.Fatalf("expected a plain 502 error, got %v", err)
+	}
+}
+
+func TestViewer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cookie") != "" || r.Header.Get("github-verified-fetch") != "" {
+			t.Errorf("unexpected browser headers on API request")
+		}
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Query != viewerQuery {
+			t.Errorf("unexpected query %s", req.Query)
+		}
+		w.Write([]byte(`{"data":{"viewer":{"login":"alice","organizations":{"nodes":[{"login":"Kong"}]}}}}`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{
+		httpClient:  ts.Client(),
+		endpoint:    "https://github.com/_graphql",
+		apiEndpoint: ts.URL,
+		token:       "token",
+		cookie:      "a=b",
+	}
+
+	viewer, err := client.Viewer(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if viewer.Login != "alice" || len(viewer.Orgs) != 1 || viewer.Orgs[0] != "Kong" || viewer.Host != "github.com" {
+		t.Fatalf("unexpected viewer: %+v", viewer)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Variables["q"] != "is:pr state:open" || req.Variables["first"] != float64(5) {
+			t.Errorf("unexpected variables: %+v", req.Variables)
+		}
+		w.Write([]byte(`{"data":{"search":{"issueCount":42,"nodes":[
+			{"__typename":"PullRequest","number":7,"title":"Fix it","url":"https://github.com/a/b/pull/7","state":"OPEN","updatedAt":"2026-10-01T10:00:00Z","author":{"login":"alice"},"repository":{"nameWithOwner":"a/b"}},
+			{"__typename":"Issue","number":3,"title":"Broken","url":"https://github.com/a/b/issues/3","state":"OPEN","updatedAt":"2026-09-30T10:00:00Z","author":null,"repository":{"nameWithOwner":"a/b"}}
+		]}}}`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{
+		httpClient:  ts.Client(),
+		apiEndpoint: ts.URL,
+		token:       "token",
+	}
+
+	result, err := client.Search(context.Background(), "is:pr state:open", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Total != 42 || len(result.Items) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	first := result.Items[0]
+	if first.Number != 7 || first.Repo != "a/b" || first.Author != "alice" || !first.PullRequest {
+		t.Fatalf("unexpected item: %+v", first)
+	}
+	if result.Items[1].Author != "" || result.Items[1].PullRequest {
+		t.Fatalf("unexpected item: %+v", result.Items[1])
+	}
+}
+
+func TestSearchClampsLimit(t *testing.T) {
+	var firsts []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		firsts = append(firsts, req.Variables["first"])
+		w.Write([]byte(`{"data":{"search":{"issueCount":0,"nodes":[]}}}`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), apiEndpoint: ts.URL, token: "token"}
+	for _, limit := range []int{0, -3, 250} {
+		if _, err := client.Search(context.Background(), "is:pr", limit); err != nil {
+			t.Fatalf("limit %d: unexpected error: %v", limit, err)
+		}
+	}
+	if want := []any{float64(1), float64(1), float64(100)}; !reflect.DeepEqual(firsts, want) {
+		t.Fatalf("expected limits clamped to 1..100, got %v", firsts)
+	}
+}
+
+func TestClientLogsRedactedMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Github-Request-Id", "ABCD:1234")
+		w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	client := &GraphQLClient{
+		httpClient: ts.Client(),
+		endpoint:   ts.URL,
+		token:      "ghp_secret",
+		cookie:     "_device_id=dev123; user_session=sess456",
+		logger:     slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	if err := client.DeleteSavedSearch(context.Background(), "SSC_123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, secret := range []string{"ghp_secret", "dev123", "sess456"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("log leaked %q: %s", secret, out)
+		}
+	}
+	for _, want := range []string{"graphql request", "status=200", "request_id=ABCD:1234", "Bearer [REDACTED]", "user_session=[REDACTED]"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in log: %s", want, out)
+		}
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	cases := []struct {
+		host, web, api string
+	}{
+		{host: "", web: "https://github.com/_graphql", api: "https://api.github.com/graphql"},
+		{host: "GitHub.com", web: "https://github.com/_graphql", api: "https://api.github.com/graphql"},
+		{host: "https://ghe.example.com/", web: "https://ghe.example.com/_graphql", api: "https://ghe.example.com/api/graphql"},
+		{host: "acme.ghe.com", web: "https://acme.ghe.com/_graphql", api: "https://api.acme.ghe.com/graphql"},
+	}
+	for _, tc := range cases {
+		web, api := Endpoints(tc.host)
+		if web != tc.web || api != tc.api {
+			t.Fatalf("%q: got %s %s", tc.host, web, api)
+		}
+	}
+}
+
+func TestNewGraphQLClientForEnterpriseHost(t *testing.T) {
+	t.Setenv("GH_TOKEN", "dotcom")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise")
+	// Keep the stored cookie lookup away from the real keyring.
+	t.Setenv("GH_SAVED_ISSUES_STORE", "file")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GH_COOKIE", "")
+	t.Setenv("GITHUB_COOKIE", "")
+	t.Setenv("GH_COOKIE_FILE", "")
+
+	client, err := NewGraphQLClientForHost(context.Background(), "ghe.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.token != "enterprise" || client.endpoint != "https://ghe.example.com/_graphql" || client.apiEndpoint != "https://ghe.example.com/api/graphql" {
+		t.Fatalf("unexpected client: %+v", client)
+	}
+	if client.Host() != "ghe.example.com" || client.origin() != "https://ghe.example.com" {
+		t.Fatalf("unexpected host %s or origin %s", client.Host(), client.origin())
+	}
+}
@@ -0,0 +1,102 @@
+package savedsearches
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and waits. The syncer uses it for timing results and
+// throttling API calls, so tests can substitute one that doesn't sleep.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d, returning early with the context's error if it is
+	// cancelled.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Limiter paces API writes. Wait blocks until the next write may be sent.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// NewRateLimiter allows at most perSecond writes per second: each write
+// waits until 1/perSecond has passed since the previous one started. Time
+// spent in the request counts towards the wait. Zero or less never waits.
+func NewRateLimiter(clock Clock, perSecond float64) Limiter {
+	var interval time.Duration
+	if perSecond > 0 {
+		interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return &intervalLimiter{clock: clock, interval: interval}
+}
+
+// NewDelayLimiter pauses for delay before every write but the first,
+// however long the previous request took.
+func NewDelayLimiter(clock Clock, delay time.Duration) Limiter {
+	return &delayLimiter{clock: clock, delay: delay}
+}
+
+type intervalLimiter struct {
+	clock    Clock
+	interval time.Duration
+	last     time.Time
+}
+
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return nil
+	}
+	if !l.last.IsZero() {
+		if wait := l.interval - l.clock.Now().Sub(l.last); wait > 0 {
+			if err := l.clock.Sleep(ctx, wait); err != nil {
+				return err
+			}
+		}
+	}
+	l.last = l.clock.Now()
+	return nil
+}
+
+type delayLimiter struct {
+	clock   Clock
+	delay   time.Duration
+	started bool
+}
+
+func (l *delayLimiter) Wait(ctx context.Context) error {
+	if l.started && l.delay > 0 {
+		if err := l.clock.Sleep(ctx, l.delay); err != nil {
+			return err
+		}
+	}
+	l.started = true
+	return nil
+}
+
+// limiters waits on each limiter in turn.
+type limiters []Limiter
+
+func (ls limiters) Wait(ctx context.Context) error {
+	for _, l := range ls {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package savedsearches
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves when it sleeps or is
+// advanced, and which records every sleep.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestSyncer builds a Syncer on a fake clock so tests never sleep.
+func newTestSyncer(client Client, opts ...Option) *Syncer {
+	return NewSyncer(client, append([]Option{WithClock(newFakeClock())}, opts...)...)
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewRateLimiter(clock, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+		// The request itself takes a while, which counts towards the wait.
+		clock.Advance(200 * time.Millisecond)
+	}
+
+	want := []time.Duration{300 * time.Millisecond, 300 * time.Millisecond}
+	if !reflect.DeepEqual(clock.sleeps, want) {
+		t.Fatalf("unexpected sleeps: %v", clock.sleeps)
+	}
+
+	unlimited := NewRateLimiter(clock, 0)
+	for i := 0; i < 3; i++ {
+		_ = unlimited.Wait(ctx)
+	}
+	if len(clock.sleeps) != 2 {
+		t.Fatalf("expected no sleeps without a rate, got %v", clock.sleeps)
+	}
+}
+
+func TestDelayLimiter(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewDelayLimiter(clock, 500*time.Millisecond)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+		clock.Advance(time.Second)
+	}
+
+	want := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}
+	if !reflect.DeepEqual(clock.sleeps, want) {
+		t.Fatalf("unexpected sleeps: %v", clock.sleeps)
+	}
+}
+
+func TestLimiterStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	limiter := NewDelayLimiter(realClock{}, time.Hour)
+	_ = limiter.Wait(ctx)
+	cancel()
+	if err := limiter.Wait(ctx); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSyncerThrottlesWrites(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{
+		{Name: "One", Query: "is:issue"},
+		{Name: "Two", Query: "is:issue"},
+		{Name: "Three", Query: "is:issue"},
+	}}
+
+	clock := newFakeClock()
+	syncer := NewSyncer(&stubClient{}, WithClock(clock), WithDelay(250*time.Millisecond))
+	if _, _, err := syncer.SyncConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	// The default of one write per second, then the extra delay. The delay
+	// counts towards the next second.
+	want := []time.Duration{time.Second, 250 * time.Millisecond, 750 * time.Millisecond, 250 * time.Millisecond}
+	if !reflect.DeepEqual(clock.sleeps, want) {
+		t.Fatalf("unexpected sleeps: %v", clock.sleeps)
+	}
+}
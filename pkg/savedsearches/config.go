@@ -5,19 +5,30 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"text/template"
 
+	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/mheap/gh-saved-issues/pkg/query"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the YAML configuration file.
 type Config struct {
+	// Host is the GitHub host the searches live on, for GitHub Enterprise.
+	Host      string                        `yaml:"host,omitempty"`
+	Accounts  map[string]AccountDefinition  `yaml:"accounts,omitempty"`
+	Vars      map[string]any                `yaml:"vars,omitempty"`
 	Searches  []SearchDefinition            `yaml:"searches"`
 	Templates map[string]TemplateDefinition `yaml:"templates"`
+	// PersistedQueries overrides the web UI query IDs when GitHub changes
+	// them before a release catches up.
+	PersistedQueries PersistedQueries `yaml:"persisted_queries,omitempty"`
 }
 
+// envVarPrefix marks environment variables that are exposed as template vars.
+const envVarPrefix = "SAVED_SEARCH_VAR_"
+
 // SearchDefinition is a single saved search definition.
 type SearchDefinition struct {
 	ID       string         `yaml:"id,omitempty"`
@@ -27,11 +38,31 @@ type SearchDefinition struct {
 	Template string         `yaml:"template,omitempty"`
 	Vars     map[string]any `yaml:"vars,omitempty"`
 	Remove   bool           `yaml:"remove,omitempty"`
+
+	// Account is the configured account the search belongs to. On a section
+	// header it applies to every entry in the section.
+	Account string `yaml:"account,omitempty"`
+	// IDAccount is the account ID and IDs were created on, empty for the
+	// default account. When the entry moves to another account they no
+	// longer point at its searches, so the syncer drops them.
+	IDAccount string `yaml:"id_account,omitempty"`
+
+	// WarnAbove highlights the search in status output when its open
+	// result count exceeds this threshold.
+	WarnAbove int `yaml:"warn_above,omitempty"`
+
+	// Matrix expands the entry into one search per combination of values.
+	Matrix map[string][]any `yaml:"matrix,omitempty"`
+	// ForEach expands the entry into one search per listed set of vars.
+	ForEach []map[string]any `yaml:"for_each,omitempty"`
+	// IDs tracks expanded searches by their combination of values.
+	IDs map[string]string `yaml:"ids,omitempty"`
 }
 
 // TemplateTemplate describes a reusable template for queries.
 type TemplateDefinition struct {
-	Query string `yaml:"query"`
+	Query   string `yaml:"query"`
+	Extends string `yaml:"extends,omitempty"`
 }
 
 // ResolveConfigPath chooses the config path based on flags and env.
@@ -52,6 +83,20 @@ func ResolveConfigPath(flagValue string) (string, error) {
 	return filepath.Join(home, ".config", ".github-searches.yaml"), nil
 }
 
+// ResolveHost chooses the GitHub host: the --hostname flag, then the config's
+// host key, then GH_HOST or the only host gh is logged in to, then
+// github.com.
+func ResolveHost(flagValue string, cfg Config) string {
+	if host := NormalizeHost(flagValue); host != "" {
+		return host
+	}
+	if host := NormalizeHost(cfg.Host); host != "" {
+		return host
+	}
+	host, _ := auth.DefaultHost()
+	return NormalizeHost(host)
+}
+
 func expandPath(path string) (string, error) {
 	if strings.HasPrefix(path, "~") {
 		home, err := os.UserHomeDir()
@@ -103,10 +148,92 @@ func SaveConfig(path string, cfg Config) error {
 	return nil
 }
 
-// RenderQuery resolves the query for a search, handling templates.
+// GlobalVars returns the vars shared by every search: the config's top-level
+// vars, overridden by SAVED_SEARCH_VAR_* environment variables, overridden by
+// the given CLI overrides.
+func (cfg Config) GlobalVars(overrides map[string]any) map[string]any {
+	return MergeVars(cfg.Vars, EnvVars(), overrides)
+}
+
+// EnvVars collects SAVED_SEARCH_VAR_<NAME> variables, keyed by lower-cased name.
+func EnvVars() map[string]any {
+	vars := map[string]any{}
+	for _, kv := range os.Environ() {
+		key, val, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, envVarPrefix) {
+			continue
+		}
+		name := strings.ToLower(strings.TrimPrefix(key, envVarPrefix))
+		if name != "" {
+			vars[name] = val
+		}
+	}
+	return vars
+}
+
+// ParseVar parses a key=value CLI override. Values starting with [ or { are
+// read as YAML so lists can be passed, e.g. repos=[repo:a, repo:b].
+func ParseVar(raw string) (string, any, error) {
+	key, val, ok := strings.Cut(raw, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid var %q: expected key=value", raw)
+	}
+
+	if strings.HasPrefix(val, "[") || strings.HasPrefix(val, "{") {
+		var parsed any
+		if err := yaml.Unmarshal([]byte(val), &parsed); err != nil {
+			return "", nil, fmt.Errorf("invalid var %q: %w", raw, err)
+		}
+		return key, parsed, nil
+	}
+
+	return key, val, nil
+}
+
+// MergeVars layers vars maps, with later maps taking precedence.
+func MergeVars(layers ...map[string]any) map[string]any {
+	merged := map[string]any{}
+	for _, layer := range layers {
+		for k, v := range layer {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// WithGlobalVars returns a copy of def whose vars are layered over globals.
+func WithGlobalVars(def SearchDefinition, globals map[string]any) SearchDefinition {
+	if len(globals) == 0 {
+		return def
+	}
+	def.Vars = MergeVars(globals, def.Vars)
+	return def
+}
+
+// RenderQuery resolves the query for a search, handling templates. Plain
+// queries are rendered as templates too, so they can reference vars. The
+// result is in canonical form (see query.Format).
 func RenderQuery(def SearchDefinition, templates map[string]TemplateDefinition) (string, error) {
+	return renderQueryAt(realClock{}, def, templates)
+}
+
+// renderQueryAt is RenderQuery with the date helpers reading clock.
+func renderQueryAt(clock Clock, def SearchDefinition, templates map[string]TemplateDefinition) (string, error) {
+	q, err := renderQuery(def, templates, clock)
+	if err != nil {
+		return "", err
+	}
+	return query.Format(q), nil
+}
+
+func renderQuery(def SearchDefinition, templates map[string]TemplateDefinition, clock Clock) (string, error) {
 	if def.Query != "" {
-		return def.Query, nil
+		if !strings.Contains(def.Query, "{{") {
+			return def.Query, nil
+		}
+		r := &templateResolver{templates: templates, clock: clock}
+		return r.renderText("query", def.Query, def.Vars)
 	}
 
 	if isSectionHeader(def) {
@@ -117,66 +244,41 @@ func RenderQuery(def SearchDefinition, templates map[string]TemplateDefinition)
 		return "", errors.New("search must have either query or template")
 	}
 
-	tpl, ok := templates[def.Template]
-	if !ok {
+	if _, ok := templates[def.Template]; !ok {
 		return "", fmt.Errorf("template %q not found", def.Template)
 	}
 
-	normalized := normalizeTemplateSyntax(tpl.Query)
-
-	funcs := template.FuncMap{
-		"default": func(value any, fallback string) string {
-			switch v := value.(type) {
-			case string:
-				if v != "" {
-					return v
-				}
-			case nil:
-				// keep empty
-			}
-			return fallback
-		},
-		"join": func(value any, sep string) string {
-			switch v := value.(type) {
-			case []any:
-				parts := make([]string, 0, len(v))
-				for _, item := range v {
-					parts = append(parts, fmt.Sprint(item))
-				}
-				return strings.Join(parts, " "+sep+" ")
-			case []string:
-				return strings.Join(v, " "+sep+" ")
-			default:
-				return fmt.Sprint(v)
-			}
-		},
-	}
-
-	for key, val := range def.Vars {
-		v := val
-		funcs[key] = func() any { return v }
-	}
-
-	for _, name := range referencedIdentifiers(normalized) {
-		if _, ok := funcs[name]; ok {
-			continue
-		}
-		name := name
-		funcs[name] = func() any {
-			_ = name
-			return nil
-		}
+	r := &templateResolver{templates: templates, clock: clock}
+	return r.render(def.Template, def.Vars)
+}
+
+// renderTemplate executes a template string against the given vars. The name
+// is only used to label errors; date helpers read clock and extra funcs are
+// added to the helper set.
+func renderTemplate(name, text string, vars map[string]any, clock Clock, extra template.FuncMap) (string, error) {
+	funcs := templateFuncs(clock)
+	for key, fn := range extra {
+		funcs[key] = fn
+	}
+
+	normalized, _, err := translateTemplate(text, funcs)
+	if err != nil {
+		return "", fmt.Errorf("parse template %q: %w", name, err)
+	}
+
+	if vars == nil {
+		vars = map[string]any{}
 	}
 
 	t, err := template.New("query").Option("missingkey=zero").Funcs(funcs).Parse(normalized)
 	if err != nil {
-		return "", fmt.Errorf("parse template %q: %w", def.Template, err)
+		return "", fmt.Errorf("parse template %q: %w", name, err)
 	}
 
 	var buf []byte
 	b := &buffer{&buf}
-	if err := t.Execute(b, def.Vars); err != nil {
-		return "", fmt.Errorf("execute template %q: %w", def.Template, err)
+	if err := t.Execute(b, vars); err != nil {
+		return "", fmt.Errorf("execute template %q: %w", name, err)
 	}
 
 	return string(buf), nil
@@ -192,62 +294,6 @@ func (b *buffer) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
-var defaultCallPattern = regexp.MustCompile(`\{\{\s*default\(\s*([^,]+?)\s*,\s*"(.*?)"\s*\)\s*\}\}`)
-var joinCallPattern = regexp.MustCompile(`\{\{\s*join\(\s*([^,]+?)\s*,\s*"(.*?)"\s*\)\s*\}\}`)
-var actionPattern = regexp.MustCompile(`\{\{[^}]+\}\}`)
-var identifierPattern = regexp.MustCompile(`([a-zA-Z_][\\w]*)`)
-
-func normalizeTemplateSyntax(in string) string {
-	out := defaultCallPattern.ReplaceAllStringFunc(in, func(m string) string {
-		parts := defaultCallPattern.FindStringSubmatch(m)
-		return fmt.Sprintf("{{ default %s \"%s\" }}", ensureDot(strings.TrimSpace(parts[1])), parts[2])
-	})
-	out = joinCallPattern.ReplaceAllStringFunc(out, func(m string) string {
-		parts := joinCallPattern.FindStringSubmatch(m)
-		return fmt.Sprintf("{{ join %s \"%s\" }}", ensureDot(strings.TrimSpace(parts[1])), parts[2])
-	})
-	return out
-}
-
-func ensureDot(expr string) string {
-	if strings.HasPrefix(expr, ".") || strings.HasPrefix(expr, "$") {
-		return expr
-	}
-	if strings.ContainsAny(expr, " ()") {
-		return expr
-	}
-	return "." + expr
-}
-
-func referencedIdentifiers(in string) []string {
-	seen := map[string]bool{}
-	var ids []string
-	for _, action := range actionPattern.FindAllString(in, -1) {
-		for _, m := range identifierPattern.FindAllStringSubmatch(action, -1) {
-			name := m[1]
-			switch name {
-			case "default", "join", "range", "end", "if", "else", "with":
-				continue
-			}
-			if seen[name] {
-				continue
-			}
-			seen[name] = true
-			ids = append(ids, name)
-		}
-	}
-	// Also check default() patterns for first argument identifiers.
-	for _, m := range defaultCallPattern.FindAllStringSubmatch(in, -1) {
-		name := strings.TrimSpace(m[1])
-		if name == "" || seen[name] {
-			continue
-		}
-		seen[name] = true
-		ids = append(ids, name)
-	}
-	return ids
-}
-
 func isSectionHeader(def SearchDefinition) bool {
 	return def.Section != "" && def.Template == "" && def.Query == ""
 }
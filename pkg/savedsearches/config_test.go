@@ -33,6 +33,26 @@ func TestResolveConfigPathFlag(t *testing.T) {
 	}
 }
 
+func TestResolveHost(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("GH_HOST", "")
+
+	if got := ResolveHost("", Config{}); got != "github.com" {
+		t.Fatalf("expected github.com by default, got %s", got)
+	}
+
+	t.Setenv("GH_HOST", "env.example.com")
+	if got := ResolveHost("", Config{}); got != "env.example.com" {
+		t.Fatalf("expected GH_HOST, got %s", got)
+	}
+	if got := ResolveHost("", Config{Host: "https://Config.example.com/"}); got != "config.example.com" {
+		t.Fatalf("expected config host over GH_HOST, got %s", got)
+	}
+	if got := ResolveHost("flag.example.com", Config{Host: "config.example.com"}); got != "flag.example.com" {
+		t.Fatalf("expected flag host, got %s", got)
+	}
+}
+
 func TestRenderQueryWithTemplate(t *testing.T) {
 	cfg := map[string]TemplateDefinition{
 		"recent": {Query: "assignee:{{ user }} updated:>@today-{{ default(time, \"7d\") }}"},
@@ -114,3 +134,70 @@ func TestRenderQueryMissingVarDefaultsToNil(t *testing.T) {
 		t.Fatalf("expected fallback resolution, got %s", query)
 	}
 }
+
+func TestRenderQueryPlainQueryUsesVars(t *testing.T) {
+	query, err := RenderQuery(SearchDefinition{
+		Query: "org:{{ org }} assignee:{{ default(user, \"@me\") }}",
+		Vars:  map[string]any{"org": "Kong"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query != "assignee:@me org:Kong" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+}
+
+func TestRenderQueryVarKeysAreNotIdentifiers(t *testing.T) {
+	key, val, err := ParseVar("my-org=Kong")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	globals := Config{Vars: map[string]any{"team-name": "platform"}}.GlobalVars(map[string]any{key: val})
+
+	query, err := RenderQuery(WithGlobalVars(SearchDefinition{
+		Query: `org:{{ index . "my-org" }} team:{{ index . "team-name" }} {{ default(user, "assignee:@me") }}`,
+	}, globals), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query != "assignee:@me org:Kong team:platform" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+}
+
+func TestGlobalVarsPrecedence(t *testing.T) {
+	t.Setenv("SAVED_SEARCH_VAR_ORG", "env-org")
+	t.Setenv("SAVED_SEARCH_VAR_USER", "env-user")
+
+	cfg := Config{Vars: map[string]any{"org": "cfg-org", "user": "cfg-user", "team": "cfg-team"}}
+	globals := cfg.GlobalVars(map[string]any{"user": "cli-user"})
+
+	if globals["team"] != "cfg-team" || globals["org"] != "env-org" || globals["user"] != "cli-user" {
+		t.Fatalf("unexpected precedence: %+v", globals)
+	}
+
+	def := WithGlobalVars(SearchDefinition{Vars: map[string]any{"user": "entry-user"}}, globals)
+	if def.Vars["user"] != "entry-user" || def.Vars["org"] != "env-org" {
+		t.Fatalf("expected entry vars to override globals, got %+v", def.Vars)
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	key, val, err := ParseVar("org=Kong")
+	if err != nil || key != "org" || val != "Kong" {
+		t.Fatalf("unexpected result: %s %v %v", key, val, err)
+	}
+
+	_, val, err = ParseVar("repos=[repo:a, repo:b]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list, ok := val.([]any); !ok || len(list) != 2 || list[1] != "repo:b" {
+		t.Fatalf("expected list value, got %#v", val)
+	}
+
+	if _, _, err := ParseVar("missing"); err == nil {
+		t.Fatalf("expected error for missing =")
+	}
+}
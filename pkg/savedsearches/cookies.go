@@ -0,0 +1,110 @@
+package savedsearches
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSessionExpired is returned when GitHub rejects the session cookie, or
+// the cookie jar says it has expired.
+var ErrSessionExpired = errors.New("session expired, refresh cookie")
+
+// sessionCookies are the cookies that make up a GitHub web session, in the
+// order they are sent. user_session is required.
+var sessionCookies = []string{"user_session", "__Host-user_session_same_site"}
+
+// ReadCookie reads a session cookie from its source: cookies:PATH for a
+// Netscape cookies.txt file, or any source ReadSecret accepts.
+func ReadCookie(source, host string) (string, error) {
+	if path, ok := strings.CutPrefix(source, "cookies:"); ok {
+		return ReadCookieJar(path, host)
+	}
+	return ReadSecret(source)
+}
+
+// ReadCookieJar returns the GitHub session cookies for host from a
+// Netscape/Mozilla cookies.txt file, as a Cookie header value.
+func ReadCookieJar(path, host string) (string, error) {
+	path, err := expandPath(path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open cookie jar: %w", err)
+	}
+	defer f.Close()
+
+	cookie, err := parseCookieJar(f, NormalizeHost(host), time.Now())
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return cookie, nil
+}
+
+// parseCookieJar picks the session cookies for host out of a cookies.txt
+// file. Each line is domain, include subdomains, path, secure, expiry (Unix
+// seconds, 0 for a browser session cookie), name and value, tab separated.
+func parseCookieJar(r io.Reader, host string, now time.Time) (string, error) {
+	type jarCookie struct {
+		value   string
+		expires time.Time
+	}
+	found := map[string]jarCookie{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// curl marks HttpOnly cookies with a prefix that otherwise looks like
+		// a comment.
+		line := strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		if !cookieDomainMatches(fields[0], fields[1] == "TRUE", host) {
+			continue
+		}
+
+		var expires time.Time
+		if secs, err := strconv.ParseInt(fields[4], 10, 64); err == nil && secs > 0 {
+			expires = time.Unix(secs, 0)
+		}
+		found[fields[5]] = jarCookie{value: fields[6], expires: expires}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read cookie jar: %w", err)
+	}
+
+	session, ok := found["user_session"]
+	if !ok {
+		return "", fmt.Errorf("no user_session cookie for %s", host)
+	}
+	if !session.expires.IsZero() && session.expires.Before(now) {
+		return "", fmt.Errorf("user_session cookie expired at %s: %w", session.expires.UTC().Format(time.RFC3339), ErrSessionExpired)
+	}
+
+	var parts []string
+	for _, name := range sessionCookies {
+		if c, ok := found[name]; ok {
+			parts = append(parts, name+"="+c.value)
+		}
+	}
+	return strings.Join(parts, "; "), nil
+}
+
+// cookieDomainMatches reports whether a cookie set for domain is sent to
+// host, which also covers subdomains when subdomains is set.
+func cookieDomainMatches(domain string, subdomains bool, host string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || (subdomains && strings.HasSuffix(host, "."+domain))
+}
@@ -0,0 +1,54 @@
+package savedsearches
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCookieJar = `# Netscape HTTP Cookie File
+# https://curl.se/docs/http-cookies.html
+
+.github.com	TRUE	/	TRUE	1900000000	_octo	GH1.1.123
+#HttpOnly_github.com	FALSE	/	TRUE	1900000000	user_session	abc123
+#HttpOnly_github.com	FALSE	/	TRUE	1900000000	__Host-user_session_same_site	abc123
+#HttpOnly_ghe.example.com	FALSE	/	TRUE	1700000000	user_session	old
+example.com	FALSE	/	FALSE	0	user_session	other
+`
+
+func TestParseCookieJar(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+
+	got, err := parseCookieJar(strings.NewReader(testCookieJar), "github.com", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "user_session=abc123; __Host-user_session_same_site=abc123" {
+		t.Fatalf("unexpected cookie: %q", got)
+	}
+
+	if _, err := parseCookieJar(strings.NewReader(testCookieJar), "ghe.example.com", now); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("expected expired session, got %v", err)
+	}
+	if _, err := parseCookieJar(strings.NewReader(testCookieJar), "gitlab.com", now); err == nil || !strings.Contains(err.Error(), "no user_session cookie for gitlab.com") {
+		t.Fatalf("expected missing cookie error, got %v", err)
+	}
+}
+
+func TestReadCookieSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(testCookieJar), 0o600); err != nil {
+		t.Fatalf("write jar: %v", err)
+	}
+	t.Setenv("WORK_COOKIE", "user_session=env")
+
+	if got, err := ReadCookie("cookies:"+path, "github.com"); err != nil || !strings.HasPrefix(got, "user_session=abc123") {
+		t.Fatalf("unexpected jar cookie: %q %v", got, err)
+	}
+	if got, err := ReadCookie("env:WORK_COOKIE", "github.com"); err != nil || got != "user_session=env" {
+		t.Fatalf("unexpected env cookie: %q %v", got, err)
+	}
+}
@@ -0,0 +1,201 @@
+package savedsearches
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ErrSecretNotFound is returned when a store has no secret by that name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// passphraseEnv holds the passphrase for the default encrypted store.
+const passphraseEnv = "GH_SAVED_ISSUES_PASSPHRASE"
+
+// kdfIterations is the PBKDF2 work factor for new store files, and
+// minKDFIterations the lowest one a store file may ask for, so a tampered
+// file can't weaken the key. Tests lower both to keep them fast.
+var (
+	kdfIterations    = 210_000
+	minKDFIterations = 100_000
+)
+
+// EncryptedStore keeps named secrets in a file encrypted with AES-256-GCM,
+// under a key derived from a passphrase. It suits machines without an OS
+// keyring, such as headless Linux.
+type EncryptedStore struct {
+	Path       string
+	Passphrase string
+}
+
+// encryptedFile is the on-disk form of an EncryptedStore. The plaintext is
+// the JSON encoding of a name to secret map.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// DefaultEncryptedStore returns the store at
+// $XDG_CONFIG_HOME/gh-saved-issues/secrets.enc (or ~/.config/...), unlocked
+// with $GH_SAVED_ISSUES_PASSPHRASE.
+func DefaultEncryptedStore() (*EncryptedStore, error) {
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("set %s to use the encrypted credential store", passphraseEnv)
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("locate home dir: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return &EncryptedStore{Path: filepath.Join(dir, "gh-saved-issues", "secrets.enc"), Passphrase: passphrase}, nil
+}
+
+// Get returns the named secret.
+func (s *EncryptedStore) Get(name string) (string, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", name, ErrSecretNotFound)
+	}
+	return value, nil
+}
+
+// Set stores a secret, replacing any with the same name.
+func (s *EncryptedStore) Set(name, value string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return s.save(secrets)
+}
+
+// Delete removes a secret. Deleting a missing secret is not an error.
+func (s *EncryptedStore) Delete(name string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return nil
+	}
+	delete(secrets, name)
+	return s.save(secrets)
+}
+
+// load decrypts the store. A missing file is an empty store.
+func (s *EncryptedStore) load() (map[string]string, error) {
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read credential store: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse credential store: %w", err)
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("credential store version %d not supported", file.Version)
+	}
+	if file.Iterations < minKDFIterations {
+		return nil, fmt.Errorf("credential store uses %d key derivation iterations, fewer than the minimum %d", file.Iterations, minKDFIterations)
+	}
+
+	gcm, err := newGCM(s.Passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, errors.New("decrypt credential store: wrong passphrase or corrupted file")
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("parse credential store: %w", err)
+	}
+	return secrets, nil
+}
+
+// save encrypts secrets with a fresh salt and nonce and writes the store.
+func (s *EncryptedStore) save(secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("marshal credential store: %w", err)
+	}
+
+	file := encryptedFile{Version: 1, Iterations: kdfIterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return fmt.Errorf("generate salt: %w", err)
+	}
+	gcm, err := newGCM(s.Passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	out, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("marshal credential store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("ensure credential store dir: %w", err)
+	}
+
+	// Write a temp file and rename it over the store, so a crash or full disk
+	// can't leave a truncated store behind.
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write credential store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write credential store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write credential store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("write credential store: %w", err)
+	}
+	return nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("credential store passphrase is empty")
+	}
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, fmt.Errorf("init cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,97 @@
+package savedsearches
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fastKDF lowers the key derivation work factor for the rest of the test.
+func fastKDF(t *testing.T) {
+	t.Helper()
+	iterations, minimum := kdfIterations, minKDFIterations
+	t.Cleanup(func() { kdfIterations, minKDFIterations = iterations, minimum })
+	kdfIterations, minKDFIterations = 10, 10
+}
+
+func TestEncryptedStore(t *testing.T) {
+	fastKDF(t)
+
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	store := &EncryptedStore{Path: path, Passphrase: "correct horse"}
+
+	if _, err := store.Get("work"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected not found in a missing store, got %v", err)
+	}
+	if err := store.Set("work", "user_session=abc"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if got, err := store.Get("work"); err != nil || got != "user_session=abc" {
+		t.Fatalf("unexpected secret: %q %v", got, err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	// The data is standard base64, which never contains "_".
+	if strings.Contains(string(raw), "user_session=abc") {
+		t.Fatalf("secret stored in plain text: %s", raw)
+	}
+
+	wrong := &EncryptedStore{Path: path, Passphrase: "battery staple"}
+	if _, err := wrong.Get("work"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("expected wrong passphrase error, got %v", err)
+	}
+
+	if err := store.Delete("work"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get("work"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected deleted secret to be gone, got %v", err)
+	}
+}
+
+func TestReadSecretFromEncryptedStore(t *testing.T) {
+	fastKDF(t)
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("GH_SAVED_ISSUES_PASSPHRASE", "correct horse")
+
+	store, err := DefaultEncryptedStore()
+	if err != nil {
+		t.Fatalf("default store: %v", err)
+	}
+	if store.Path != filepath.Join(dir, "gh-saved-issues", "secrets.enc") {
+		t.Fatalf("unexpected store path %s", store.Path)
+	}
+	if err := store.Set("personal-cookie", "user_session=xyz"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	if got, err := ReadSecret("encrypted:personal-cookie"); err != nil || got != "user_session=xyz" {
+		t.Fatalf("unexpected secret: %q %v", got, err)
+	}
+}
+
+func TestEncryptedStoreRejectsWeakKDF(t *testing.T) {
+	fastKDF(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.enc")
+	store := &EncryptedStore{Path: path, Passphrase: "correct horse"}
+	if err := store.Set("work", "user_session=abc"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Fatalf("expected only the store file after saving, got %v %v", entries, err)
+	}
+
+	minKDFIterations = 11
+	if _, err := store.Get("work"); err == nil || !strings.Contains(err.Error(), "fewer than the minimum 11") {
+		t.Fatalf("expected the weak store to be rejected, got %v", err)
+	}
+}
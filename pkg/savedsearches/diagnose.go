@@ -0,0 +1,138 @@
+package savedsearches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TokenInfo describes the token a client authenticates with.
+type TokenInfo struct {
+	Login string
+	// Scopes are the OAuth scopes GitHub reports for the token. They are nil
+	// when GitHub reports none, as for fine-grained and app tokens.
+	Scopes []string
+}
+
+const tokenQuery = `query { viewer { login } }`
+
+// TokenInfo looks up who the token belongs to and its scopes through the
+// public GraphQL API.
+func (c *GraphQLClient) TokenInfo(ctx context.Context) (TokenInfo, error) {
+	endpoint := c.apiEndpoint
+	if endpoint == "" {
+		endpoint = defaultAPIEndpoint
+	}
+	data, header, err := c.send(ctx, endpoint, tokenQuery, nil, false)
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("check token: %w", err)
+	}
+
+	var info TokenInfo
+	v, _ := data["viewer"].(map[string]any)
+	info.Login, _ = v["login"].(string)
+	if values, ok := header["X-Oauth-Scopes"]; ok {
+		info.Scopes = []string{}
+		for _, scope := range strings.Split(strings.Join(values, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+	return info, nil
+}
+
+// Ping checks that the web host answers at all, without credentials.
+func (c *GraphQLClient) Ping(ctx context.Context) error {
+	resp, _, err := c.getPage(ctx, false)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%s answered with status %d", c.origin(), resp.StatusCode)
+	}
+	return nil
+}
+
+// userLoginMeta is how GitHub pages name the signed-in user.
+var userLoginMeta = regexp.MustCompile(`<meta name="user-login" content="([^"]*)"`)
+
+// SessionLogin loads the host's home page with the session cookie, a
+// harmless read, and returns the login it is signed in as. A cookie GitHub
+// no longer accepts gives ErrSessionExpired.
+func (c *GraphQLClient) SessionLogin(ctx context.Context) (string, error) {
+	if c.cookie == "" {
+		return "", errors.New("no session cookie; run login or set GH_COOKIE")
+	}
+	resp, body, err := c.getPage(ctx, true)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("%s answered with status %d", c.origin(), resp.StatusCode)
+	}
+	m := userLoginMeta.FindSubmatch(body)
+	if m == nil || len(m[1]) == 0 {
+		return "", ErrSessionExpired
+	}
+	return string(m[1]), nil
+}
+
+// getPage fetches the host's home page, with the session cookie if
+// withCookie is set. Only the first megabyte of the body is read.
+func (c *GraphQLClient) getPage(ctx context.Context, withCookie bool) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.origin()+"/", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build request: %w", err)
+	}
+	if withCookie {
+		req.Header.Set("Cookie", c.cookie)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logRequest(req, 0, nil, 0, time.Since(start), err)
+		return nil, nil, fmt.Errorf("get %s: %w", req.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	c.logRequest(req, 0, resp, len(body), time.Since(start), err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", req.URL, err)
+	}
+	return resp, body, nil
+}
+
+// PersistedOperations are the web UI operations the client relies on, in
+// the order CheckPersistedQuery is usually run.
+var PersistedOperations = []string{"create", "update", "delete", "list"}
+
+// CheckPersistedQuery checks that GitHub still knows the persisted query for
+// an operation. It sends the query without its variables, which GitHub
+// rejects before running anything.
+func (c *GraphQLClient) CheckPersistedQuery(ctx context.Context, operation string) error {
+	if !slices.Contains(PersistedOperations, operation) {
+		return fmt.Errorf("unknown operation %q", operation)
+	}
+	if c.queryID(operation) == "" {
+		return ErrListUnavailable
+	}
+
+	_, err := c.persistedQuery(ctx, operation, map[string]any{})
+	if errors.Is(err, ErrPersistedQueryNotFound) {
+		return err
+	}
+	// Complaints about the missing input mean the query was found.
+	if errors.As(err, new(*GraphQLError)) {
+		return nil
+	}
+	return err
+}
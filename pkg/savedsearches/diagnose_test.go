@@ -0,0 +1,95 @@
+package savedsearches
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenInfo(t *testing.T) {
+	scopes := "repo, read:org"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scopes != "-" {
+			w.Header().Set("X-OAuth-Scopes", scopes)
+		}
+		w.Write([]byte(`{"data":{"viewer":{"login":"alice"}}}`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), apiEndpoint: ts.URL, token: "token"}
+	info, err := client.TokenInfo(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Login != "alice" || !reflect.DeepEqual(info.Scopes, []string{"repo", "read:org"}) {
+		t.Fatalf("unexpected token info: %+v", info)
+	}
+
+	scopes = "-"
+	if info, _ := client.TokenInfo(context.Background()); info.Scopes != nil {
+		t.Fatalf("expected unknown scopes for a fine-grained token, got %+v", info.Scopes)
+	}
+}
+
+func TestSessionLogin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.Header.Get("Cookie") == "user_session=good" {
+			w.Write([]byte(`<html><head><meta name="user-login" content="alice"></head></html>`))
+			return
+		}
+		w.Write([]byte(`<html><head><meta name="user-login" content=""></head></html>`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), endpoint: ts.URL + "/_graphql", cookie: "user_session=good"}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if login, err := client.SessionLogin(context.Background()); err != nil || login != "alice" {
+		t.Fatalf("unexpected login: %q %v", login, err)
+	}
+
+	client.cookie = "user_session=stale"
+	if _, err := client.SessionLogin(context.Background()); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("expected expired session, got %v", err)
+	}
+
+	client.cookie = ""
+	if _, err := client.SessionLogin(context.Background()); err == nil || !strings.Contains(err.Error(), "no session cookie") {
+		t.Fatalf("expected missing cookie error, got %v", err)
+	}
+}
+
+func TestCheckPersistedQuery(t *testing.T) {
+	var variables []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		variables = append(variables, req.Variables)
+		if req.Query == deletePersistedID {
+			w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+			return
+		}
+		w.Write([]byte(`{"errors":[{"message":"Variable $input of type CreateDashboardSearchShortcutInput! was provided invalid value"}]}`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), endpoint: ts.URL, token: "token", cookie: "a=b"}
+	if err := client.CheckPersistedQuery(context.Background(), "create"); err != nil {
+		t.Fatalf("expected create query to be found, got %v", err)
+	}
+	if err := client.CheckPersistedQuery(context.Background(), "delete"); err == nil || !strings.Contains(err.Error(), "delete query") {
+		t.Fatalf("expected stale delete query, got %v", err)
+	}
+	for _, v := range variables {
+		if len(v) != 0 {
+			t.Fatalf("expected no variables, got %+v", v)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package savedsearches
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/mheap/gh-saved-issues/pkg/query"
+)
+
+// EventKind identifies a step in a sync.
+type EventKind string
+
+const (
+	// EventEntryStarted fires before a configured search is rendered.
+	EventEntryStarted EventKind = "entry_started"
+	// EventActionPlanned fires once the action for a search is known, with
+	// any lint diagnostics for its query.
+	EventActionPlanned EventKind = "action_planned"
+	// EventAPICall fires after each create, update or delete request.
+	EventAPICall EventKind = "api_call"
+	// EventEntryFinished fires with the result for a search.
+	EventEntryFinished EventKind = "entry_finished"
+	// EventError fires when a search fails.
+	EventError EventKind = "error"
+)
+
+// Event describes a step in a sync. Fields that don't apply to the kind are
+// left empty.
+type Event struct {
+	Kind   EventKind
+	Name   string
+	ID     string
+	Action Action
+	DryRun bool
+
+	Diagnostics []query.Diagnostic // EventActionPlanned
+	Call        string             // EventAPICall: create, update or delete
+	Duration    time.Duration      // EventAPICall
+	Result      *EntryResult       // EventEntryFinished
+	Err         error              // EventAPICall and EventError
+}
+
+// Observer receives sync events.
+type Observer interface {
+	OnEvent(Event)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(Event)
+
+// OnEvent calls f.
+func (f ObserverFunc) OnEvent(e Event) { f(e) }
+
+// ProgressObserver writes the familiar "Processing: NAME" lines and lint
+// diagnostics to w. Dry runs are ignored so a plan is quiet.
+func ProgressObserver(w io.Writer) Observer {
+	return ObserverFunc(func(e Event) {
+		if e.DryRun {
+			return
+		}
+		switch e.Kind {
+		case EventEntryStarted:
+			fmt.Fprintln(w, "Processing: "+e.Name)
+		case EventActionPlanned:
+			if e.Action == ActionDelete {
+				fmt.Fprintln(w, "Removing: "+e.Name)
+			}
+			for _, d := range e.Diagnostics {
+				fmt.Fprintf(w, "%s: %s\n", e.Name, d)
+			}
+		}
+	})
+}
+
+// logEvent writes an event to logger: steps at debug level, outcomes at info
+// and failures at error.
+func logEvent(logger *slog.Logger, e Event) {
+	attrs := []any{slog.String("name", e.Name)}
+	if e.ID != "" {
+		attrs = append(attrs, slog.String("id", e.ID))
+	}
+	if e.Action != "" {
+		attrs = append(attrs, slog.String("action", string(e.Action)))
+	}
+	if e.DryRun {
+		attrs = append(attrs, slog.Bool("dry_run", true))
+	}
+
+	switch e.Kind {
+	case EventEntryStarted:
+		logger.Debug("entry started", attrs...)
+	case EventActionPlanned:
+		if len(e.Diagnostics) > 0 {
+			attrs = append(attrs, slog.Int("diagnostics", len(e.Diagnostics)))
+		}
+		logger.Debug("action planned", attrs...)
+	case EventAPICall:
+		attrs = append(attrs, slog.String("call", e.Call), slog.Duration("duration", e.Duration))
+		if e.Err != nil {
+			attrs = append(attrs, slog.String("error", e.Err.Error()))
+		}
+		logger.Debug("api call", attrs...)
+	case EventEntryFinished:
+		if e.Result != nil {
+			attrs = append(attrs, slog.Int64("duration_ms", e.Result.DurationMS))
+		}
+		logger.Info("entry finished", attrs...)
+	case EventError:
+		logger.Error("entry failed", append(attrs, slog.String("error", e.Err.Error()))...)
+	}
+}
@@ -0,0 +1,99 @@
+package savedsearches
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeEventsConfig(t *testing.T) string {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "config.yaml")
+	cfgYAML := `
+searches:
+  - name: Create
+    query: state:open
+  - name: Remove
+    id: SSC_remove
+    query: is:issue
+    remove: true
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	return cfgPath
+}
+
+func TestSyncerEmitsEvents(t *testing.T) {
+	cfgPath := writeEventsConfig(t)
+
+	var kinds []string
+	syncer := newTestSyncer(&stubClient{}, WithObserver(ObserverFunc(func(e Event) {
+		label := string(e.Kind) + " " + e.Name
+		if e.Call != "" {
+			label += " " + e.Call
+		}
+		kinds = append(kinds, label)
+	})))
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	want := []string{
+		"entry_started Create",
+		"action_planned Create",
+		"api_call Create create",
+		"entry_finished Create",
+		"entry_started Remove",
+		"action_planned Remove",
+		"api_call Remove delete",
+		"entry_finished Remove",
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("unexpected events:\n got %q\nwant %q", kinds, want)
+	}
+}
+
+func TestSyncerEmitsErrorEvent(t *testing.T) {
+	cfgPath := writeEventsConfig(t)
+
+	var failures []Event
+	syncer := newTestSyncer(&stubClient{err: errors.New("boom")}, WithObserver(ObserverFunc(func(e Event) {
+		if e.Kind == EventError {
+			failures = append(failures, e)
+		}
+	})))
+	if err := syncer.Sync(context.Background(), cfgPath); err == nil {
+		t.Fatalf("expected sync error")
+	}
+
+	if len(failures) != 1 || failures[0].Name != "Create" || failures[0].Action != ActionCreate || !strings.Contains(failures[0].Err.Error(), "boom") {
+		t.Fatalf("unexpected error events: %+v", failures)
+	}
+}
+
+func TestProgressObserver(t *testing.T) {
+	cfgPath := writeEventsConfig(t)
+
+	var buf bytes.Buffer
+	syncer := newTestSyncer(&stubClient{}, WithObserver(ProgressObserver(&buf)))
+	if _, err := syncer.Plan(context.Background(), cfgPath); err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected a quiet plan, got %q", buf.String())
+	}
+
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	want := "Processing: Create\nCreate: warning: column 1: query matches both issues and pull requests; add is:issue or is:pr\nProcessing: Remove\nRemoving: Remove\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected progress:\n%s", buf.String())
+	}
+}
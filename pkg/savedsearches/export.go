@@ -0,0 +1,129 @@
+package savedsearches
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ExportFormats lists the formats supported by Export.
+var ExportFormats = []string{"markdown", "html", "csv", "json"}
+
+// exportedSearch is the JSON shape of an exported search.
+type exportedSearch struct {
+	Name    string `json:"name"`
+	Section string `json:"section,omitempty"`
+	Query   string `json:"query"`
+	URL     string `json:"url"`
+}
+
+// SearchURL returns the issues search URL for a query on host.
+func SearchURL(host, q string) string {
+	if host == "" {
+		host = "github.com"
+	}
+	return "https://" + host + "/issues?q=" + url.QueryEscape(q)
+}
+
+// Export writes the rendered searches in the given format. Section headers
+// become headings or folders where the format has them.
+func Export(w io.Writer, format string, searches []RenderedSearch, host string) error {
+	switch format {
+	case "markdown", "md":
+		return exportMarkdown(w, searches, host)
+	case "html":
+		return exportHTML(w, searches, host)
+	case "csv":
+		return exportCSV(w, searches, host)
+	case "json":
+		return exportJSON(w, searches, host)
+	default:
+		return fmt.Errorf("unknown export format %q (want one of %s)", format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+func exportMarkdown(w io.Writer, searches []RenderedSearch, host string) error {
+	var b strings.Builder
+	b.WriteString("# Saved searches\n")
+	listOpen := false
+	for _, s := range searches {
+		if s.Header {
+			fmt.Fprintf(&b, "\n## %s\n", s.Section)
+			listOpen = false
+			continue
+		}
+		if !listOpen {
+			b.WriteString("\n")
+			listOpen = true
+		}
+		name := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s.Name)
+		fmt.Fprintf(&b, "- [%s](%s): `%s`\n", name, SearchURL(host, s.Query), strings.ReplaceAll(s.Query, "`", "'"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func exportHTML(w io.Writer, searches []RenderedSearch, host string) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n")
+	b.WriteString(`<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">` + "\n")
+	b.WriteString("<TITLE>Saved searches</TITLE>\n<H1>Saved searches</H1>\n<DL><p>\n")
+
+	inFolder := false
+	for _, s := range searches {
+		if s.Header {
+			if inFolder {
+				b.WriteString("    </DL><p>\n")
+			}
+			fmt.Fprintf(&b, "    <DT><H3>%s</H3>\n    <DL><p>\n", html.EscapeString(s.Section))
+			inFolder = true
+			continue
+		}
+		indent := "    "
+		if inFolder {
+			indent = "        "
+		}
+		fmt.Fprintf(&b, "%s<DT><A HREF=\"%s\">%s</A>\n", indent, html.EscapeString(SearchURL(host, s.Query)), html.EscapeString(s.Name))
+	}
+	if inFolder {
+		b.WriteString("    </DL><p>\n")
+	}
+	b.WriteString("</DL><p>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func exportCSV(w io.Writer, searches []RenderedSearch, host string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"name", "section", "query", "url"}); err != nil {
+		return err
+	}
+	for _, s := range searches {
+		if s.Header {
+			continue
+		}
+		if err := cw.Write([]string{s.Name, s.Section, s.Query, SearchURL(host, s.Query)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func exportJSON(w io.Writer, searches []RenderedSearch, host string) error {
+	out := []exportedSearch{}
+	for _, s := range searches {
+		if s.Header {
+			continue
+		}
+		out = append(out, exportedSearch{Name: s.Name, Section: s.Section, Query: s.Query, URL: SearchURL(host, s.Query)})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,79 @@
+package savedsearches
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var exportSearches = []RenderedSearch{
+	{Name: "Untitled", Query: "is:issue"},
+	{Name: "== Team ==", Section: "Team", Header: true},
+	{Name: "Team PRs", Section: "Team", Query: `is:pr label:"needs review" (repo:a/b OR repo:c/d)`},
+}
+
+func TestSearchURL(t *testing.T) {
+	got := SearchURL("", `is:pr label:"needs review"`)
+	want := "https://github.com/issues?q=is%3Apr+label%3A%22needs+review%22"
+	if got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, "markdown", exportSearches, "github.com"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	want := "# Saved searches\n\n" +
+		"- [Untitled](https://github.com/issues?q=is%3Aissue): `is:issue`\n" +
+		"\n## Team\n\n" +
+		"- [Team PRs](https://github.com/issues?q=is%3Apr+label%3A%22needs+review%22+%28repo%3Aa%2Fb+OR+repo%3Ac%2Fd%29): `is:pr label:\"needs review\" (repo:a/b OR repo:c/d)`\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected markdown:\n%s", buf.String())
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, "html", exportSearches, "github.com"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"<!DOCTYPE NETSCAPE-Bookmark-file-1>",
+		"    <DT><A HREF=\"https://github.com/issues?q=is%3Aissue\">Untitled</A>\n",
+		"    <DT><H3>Team</H3>\n    <DL><p>\n",
+		"        <DT><A HREF=\"https://github.com/issues?q=is%3Apr+label%3A%22needs+review%22",
+		"    </DL><p>\n</DL><p>\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestExportCSVAndJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, "csv", exportSearches, "github.com"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != "name,section,query,url" || !strings.HasPrefix(lines[2], `Team PRs,Team,"is:pr label:""needs review""`) {
+		t.Fatalf("unexpected csv:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Export(&buf, "json", exportSearches, "github.com"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"url": "https://github.com/issues?q=is%3Aissue"`) {
+		t.Fatalf("unexpected json:\n%s", buf.String())
+	}
+
+	if err := Export(&buf, "yaml", exportSearches, "github.com"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
@@ -0,0 +1,447 @@
+package savedsearches
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The README documents a function-call syntax for template helpers, e.g.
+// {{ default(time, "30d") }}. text/template only understands space separated
+// calls, so each action is tokenized and call expressions are rewritten into
+// the equivalent parenthesised form before parsing. Vars are template data
+// rather than functions, so a bare var name is rewritten into a lookup on the
+// root data; everything else is passed through untouched so plain Go template
+// actions keep working.
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokString
+	tokNumber
+	tokLParen
+	tokRParen
+	tokComma
+	tokOther
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int  // byte offset into the template source
+	space bool // preceded by whitespace
+}
+
+func (t token) end() int { return t.pos + len(t.text) }
+
+// syntaxError is a template syntax error at a byte offset in the source.
+type syntaxError struct {
+	pos int
+	msg string
+}
+
+func (e *syntaxError) Error() string { return e.msg }
+
+// templateKeywords are words text/template handles itself; they are never
+// rewritten into var lookups.
+var templateKeywords = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true,
+	"define": true, "template": true, "block": true, "break": true,
+	"continue": true, "nil": true, "true": true, "false": true,
+	"and": true, "or": true, "not": true, "len": true, "index": true,
+	"slice": true, "print": true, "printf": true, "println": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"html": true, "js": true, "urlquery": true, "call": true,
+}
+
+// translateTemplate rewrites function-call syntax into text/template syntax.
+// Identifiers that aren't keywords or one of funcs are treated as vars. It
+// also returns the var names used in actions.
+func translateTemplate(src string, funcs template.FuncMap) (string, []string, error) {
+	var out strings.Builder
+	seen := map[string]bool{}
+	var idents []string
+
+	i := 0
+	for {
+		start := strings.Index(src[i:], "{{")
+		if start < 0 {
+			out.WriteString(src[i:])
+			break
+		}
+		start += i
+		out.WriteString(src[i:start])
+
+		end, err := findActionEnd(src, start+2)
+		if err != nil {
+			return "", nil, positionError(src, err)
+		}
+
+		inner := src[start+2 : end]
+		translated, names, err := translateAction(inner, start+2, funcs)
+		if err != nil {
+			return "", nil, positionError(src, err)
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				idents = append(idents, name)
+			}
+		}
+
+		out.WriteString("{{")
+		out.WriteString(translated)
+		out.WriteString("}}")
+		i = end + 2
+	}
+
+	return out.String(), idents, nil
+}
+
+// findActionEnd returns the offset of the closing braces for an action,
+// ignoring any that appear inside quoted strings.
+func findActionEnd(src string, from int) (int, error) {
+	for i := from; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"', '\'', '`':
+			j, err := skipString(src, i)
+			if err != nil {
+				return 0, err
+			}
+			i = j - 1
+		case '}':
+			if i+1 < len(src) && src[i+1] == '}' {
+				return i, nil
+			}
+		}
+	}
+	return 0, &syntaxError{pos: from - 2, msg: "unclosed action"}
+}
+
+// skipString returns the offset just past the string starting at i.
+func skipString(src string, i int) (int, error) {
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			return j + 1, nil
+		}
+	}
+	return 0, &syntaxError{pos: i, msg: "unterminated string"}
+}
+
+func translateAction(inner string, base int, funcs template.FuncMap) (string, []string, error) {
+	// Comments and trim markers are passed through as-is.
+	if strings.HasPrefix(strings.TrimLeft(inner, " -"), "/*") {
+		return inner, nil, nil
+	}
+
+	prefix, suffix := "", ""
+	body := inner
+	if strings.HasPrefix(body, "- ") {
+		prefix, body = "- ", body[2:]
+		base += 2
+	}
+	if strings.HasSuffix(body, " -") {
+		suffix, body = " -", body[:len(body)-2]
+	}
+
+	tokens, err := tokenize(body, base)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := &exprParser{tokens: tokens, funcs: funcs}
+	translated, err := p.translate()
+	if err != nil {
+		return "", nil, err
+	}
+	if strings.TrimRight(body, " \t\r\n") != body {
+		translated += " "
+	}
+
+	return prefix + translated + suffix, p.idents, nil
+}
+
+func tokenize(src string, base int) ([]token, error) {
+	var tokens []token
+	space := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+			continue
+		case c == '"' || c == '\'' || c == '`':
+			j, err := skipString(src, i)
+			if err != nil {
+				err.(*syntaxError).pos += base
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: src[i:j], pos: base + i, space: space})
+			i = j
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: base + i, space: space})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: base + i, space: space})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: base + i, space: space})
+			i++
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			j := i + 1
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.' || src[j] == '_' || letterWidth(src[j:]) > 0) {
+				j += max(letterWidth(src[j:]), 1)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[i:j], pos: base + i, space: space})
+			i = j
+		case letterWidth(src[i:]) > 0 || c == '_' || c == '.' || c == '$':
+			j := i + max(letterWidth(src[i:]), 1)
+			for j < len(src) && (letterWidth(src[j:]) > 0 || isDigit(src[j]) || src[j] == '_' || src[j] == '.') {
+				j += max(letterWidth(src[j:]), 1)
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[i:j], pos: base + i, space: space})
+			i = j
+		default:
+			j := i + 1
+			for j < len(src) && !strings.ContainsRune(" \t\r\n\"'`(),", rune(src[j])) &&
+				letterWidth(src[j:]) == 0 && !isDigit(src[j]) && src[j] != '_' && src[j] != '.' && src[j] != '$' {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokOther, text: src[i:j], pos: base + i, space: space})
+			i = j
+		}
+		space = false
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+	funcs  template.FuncMap
+	idents []string
+}
+
+func (p *exprParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// isCall reports whether the token at the current position starts a call:
+// an identifier immediately followed by an opening parenthesis.
+func (p *exprParser) isCall() bool {
+	if p.pos+1 >= len(p.tokens) {
+		return false
+	}
+	name, open := p.tokens[p.pos], p.tokens[p.pos+1]
+	return name.kind == tokIdent && open.kind == tokLParen && open.pos == name.end() &&
+		!strings.HasPrefix(name.text, ".") && !strings.HasPrefix(name.text, "$")
+}
+
+// translate rewrites every call in the action and passes other tokens through.
+func (p *exprParser) translate() (string, error) {
+	var out strings.Builder
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		if tok.space {
+			out.WriteByte(' ')
+		}
+
+		if p.isCall() {
+			call, err := p.parseCall()
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(call)
+			continue
+		}
+
+		switch tok.kind {
+		case tokString:
+			out.WriteString(normalizeString(tok.text))
+		case tokIdent:
+			if p.isVar(tok.text) {
+				p.noteIdent(tok.text)
+				out.WriteString("$." + tok.text)
+			} else {
+				out.WriteString(tok.text)
+			}
+		default:
+			out.WriteString(tok.text)
+		}
+		p.pos++
+	}
+	return out.String(), nil
+}
+
+// parseCall parses name(arg, ...) and returns "(name arg ...)".
+func (p *exprParser) parseCall() (string, error) {
+	name := p.tokens[p.pos]
+	open := p.tokens[p.pos+1]
+	p.pos += 2
+
+	args := []string{name.text}
+	if tok, ok := p.peek(); ok && tok.kind == tokRParen {
+		p.pos++
+		return "(" + strings.Join(args, " ") + ")", nil
+	}
+
+	for {
+		arg, err := p.parseArg(open)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+
+		tok, ok := p.peek()
+		if !ok {
+			return "", &syntaxError{pos: open.pos, msg: fmt.Sprintf("unclosed '(' in call to %s", name.text)}
+		}
+		p.pos++
+		switch tok.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return "(" + strings.Join(args, " ") + ")", nil
+		default:
+			return "", &syntaxError{pos: tok.pos, msg: fmt.Sprintf("expected ',' or ')' in call to %s, found %q", name.text, tok.text)}
+		}
+	}
+}
+
+// parseArg parses a single call argument: a nested call, a variable,
+// a string or number literal, or a parenthesised argument.
+func (p *exprParser) parseArg(open token) (string, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return "", &syntaxError{pos: open.pos, msg: "unclosed '('"}
+	}
+
+	if p.isCall() {
+		return p.parseCall()
+	}
+
+	switch tok.kind {
+	case tokIdent:
+		p.pos++
+		switch {
+		case strings.HasPrefix(tok.text, ".") || strings.HasPrefix(tok.text, "$"):
+			return tok.text, nil
+		case tok.text == "true" || tok.text == "false" || tok.text == "nil":
+			return tok.text, nil
+		default:
+			p.noteIdent(tok.text)
+			return "$." + tok.text, nil
+		}
+	case tokString:
+		p.pos++
+		return normalizeString(tok.text), nil
+	case tokNumber:
+		p.pos++
+		return tok.text, nil
+	case tokLParen:
+		p.pos++
+		inner, err := p.parseArg(tok)
+		if err != nil {
+			return "", err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokRParen {
+			return "", &syntaxError{pos: tok.pos, msg: "unclosed '('"}
+		}
+		p.pos++
+		return "(" + inner + ")", nil
+	case tokComma, tokRParen:
+		return "", &syntaxError{pos: tok.pos, msg: fmt.Sprintf("missing argument before %q", tok.text)}
+	default:
+		return "", &syntaxError{pos: tok.pos, msg: fmt.Sprintf("unexpected %q in arguments", tok.text)}
+	}
+}
+
+// isVar reports whether a bare identifier in an action refers to a var
+// rather than a keyword, a function or the template's own data and variables.
+func (p *exprParser) isVar(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "$") {
+		return false
+	}
+	head, _, _ := strings.Cut(name, ".")
+	if templateKeywords[head] {
+		return false
+	}
+	_, ok := p.funcs[head]
+	return !ok
+}
+
+func (p *exprParser) noteIdent(name string) {
+	head, _, _ := strings.Cut(name, ".")
+	p.idents = append(p.idents, head)
+}
+
+// normalizeString converts single-quoted strings to Go string literals.
+func normalizeString(lit string) string {
+	if lit[0] != '\'' {
+		return lit
+	}
+	body := lit[1 : len(lit)-1]
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\\' && i+1 < len(body) {
+			i++
+			switch body[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(body[i])
+			}
+			continue
+		}
+		b.WriteByte(body[i])
+	}
+	return strconv.Quote(b.String())
+}
+
+// positionError converts a syntaxError offset into a line:column message.
+func positionError(src string, err error) error {
+	se, ok := err.(*syntaxError)
+	if !ok {
+		return err
+	}
+	line, col := 1, 1
+	for i := 0; i < se.pos && i < len(src); i++ {
+		if src[i] == '\n' {
+			line++
+			col = 1
+			continue
+		}
+		col++
+	}
+	return fmt.Errorf("%d:%d: %s", line, col, se.msg)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// letterWidth returns the byte length of the letter s starts with, or 0 if
+// it doesn't start with one. Identifiers may use any Unicode letter, as in
+// text/template, so whole runes are decoded rather than single bytes.
+func letterWidth(s string) int {
+	r, n := utf8.DecodeRuneInString(s)
+	if !unicode.IsLetter(r) {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,79 @@
+package savedsearches
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplateCallSyntax(t *testing.T) {
+	vars := map[string]any{
+		"repos":    []any{"repo:a", "repo:b"},
+		"sep":      "OR",
+		"fallback": "14d",
+		"user":     "alice",
+		"größe":    "L",
+	}
+
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"nested", `{{ join(default(missing, repos), "OR") }}`, "repo:a OR repo:b"},
+		{"single quotes", `updated:>@today-{{ default(time, '30d') }}`, "updated:>@today-30d"},
+		{"comma in string", `{{ default(missing, "a, b") }}`, "a, b"},
+		{"braces in string", `{{ default(missing, "}}") }}`, "}}"},
+		{"variable argument", `{{ join(repos, sep) }} {{ default(time, fallback) }}`, "repo:a OR repo:b 14d"},
+		{"bare identifier", `author:{{ user }}`, "author:alice"},
+		{"go template syntax", `{{ if user }}author:{{ .user }}{{ end }}`, "author:alice"},
+		{"unicode identifier", `size:{{ default(größe, "M") }} label:"{{ default(missing, "déjà") }}"`, `size:L label:"déjà"`},
+		{"trim markers", `a {{- default(missing, "b") -}} c`, "abc"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := renderTemplate("test", tc.in, vars, realClock{}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateTemplateErrorPositions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{`is:open {{ default(time "7d") }}`, `1:25: expected ',' or ')' in call to default, found "\"7d\""`},
+		{"is:open\n{{ join(repos, ) }}", `2:16: missing argument before ")"`},
+		{`{{ default(time, "7d") `, "1:1: unclosed action"},
+		{`{{ default(time, "7d }}`, "1:18: unterminated string"},
+		{`{{ join(default(x, "y"), "OR" }}`, "1:8: unclosed '(' in call to join"},
+	}
+
+	for _, tc := range cases {
+		_, _, err := translateTemplate(tc.in, templateFuncs(realClock{}))
+		if err == nil {
+			t.Fatalf("%s: expected error", tc.in)
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected %q, got %q", tc.in, tc.want, err.Error())
+		}
+	}
+}
+
+func TestRenderTemplateVarsDontShadowHelpers(t *testing.T) {
+	vars := map[string]any{"join": "x", "date": "y", "repos": []any{"repo:a", "repo:b"}}
+
+	got, err := renderTemplate("test", `{{ join(repos, "OR") }} {{ .join }}`, vars, realClock{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "repo:a OR repo:b x" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package savedsearches
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mheap/gh-saved-issues/pkg/query"
+)
+
+// FormatConfig returns a copy of cfg with every query rewritten in canonical
+// form, along with the names of the entries and templates that changed.
+// Queries containing template actions only have the whitespace between
+// actions collapsed, since their final shape isn't known until rendering.
+func FormatConfig(cfg Config) (Config, []string) {
+	var changed []string
+
+	searches := make([]SearchDefinition, len(cfg.Searches))
+	copy(searches, cfg.Searches)
+	for i := range searches {
+		formatted := formatQueryText(searches[i].Query)
+		if formatted != searches[i].Query {
+			searches[i].Query = formatted
+			changed = append(changed, searches[i].Name)
+		}
+	}
+	cfg.Searches = searches
+
+	if cfg.Templates != nil {
+		names := make([]string, 0, len(cfg.Templates))
+		for name := range cfg.Templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		templates := make(map[string]TemplateDefinition, len(cfg.Templates))
+		for _, name := range names {
+			tpl := cfg.Templates[name]
+			formatted := formatQueryText(tpl.Query)
+			if formatted != tpl.Query {
+				tpl.Query = formatted
+				changed = append(changed, "template "+name)
+			}
+			templates[name] = tpl
+		}
+		cfg.Templates = templates
+	}
+
+	return cfg, changed
+}
+
+func formatQueryText(text string) string {
+	if !strings.Contains(text, "{{") {
+		return query.Format(text)
+	}
+
+	var out strings.Builder
+	for i := 0; ; {
+		start := strings.Index(text[i:], "{{")
+		if start < 0 {
+			out.WriteString(collapseSpace(text[i:]))
+			break
+		}
+		start += i
+		end, err := findActionEnd(text, start+2)
+		if err != nil {
+			// Leave broken templates for RenderQuery to report.
+			return text
+		}
+		out.WriteString(collapseSpace(text[i:start]))
+		out.WriteString(text[start : end+2])
+		i = end + 2
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// collapseSpace replaces runs of whitespace with a single space.
+func collapseSpace(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	out := strings.Join(fields, " ")
+	if strings.TrimLeft(s, " \t\r\n") != s {
+		out = " " + out
+	}
+	if strings.TrimRight(s, " \t\r\n") != s {
+		out += " "
+	}
+	return out
+}
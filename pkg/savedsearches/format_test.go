@@ -0,0 +1,42 @@
+package savedsearches
+
+import "testing"
+
+func TestFormatConfig(t *testing.T) {
+	cfg := Config{
+		Searches: []SearchDefinition{
+			{Name: "Messy", Query: "state:open   is:pr  archived:false"},
+			{Name: "Clean", Query: "is:pr state:open"},
+			{Name: "Templated", Query: "state:open   author:{{ default(user,  \"@me\") }}  is:pr"},
+		},
+		Templates: map[string]TemplateDefinition{
+			"repos": {Query: "is:pr  ({{ join(repos, \"OR\") }})  "},
+		},
+	}
+
+	formatted, changed := FormatConfig(cfg)
+
+	if formatted.Searches[0].Query != "archived:false is:pr state:open" {
+		t.Fatalf("unexpected formatted query: %s", formatted.Searches[0].Query)
+	}
+	if formatted.Searches[2].Query != "state:open author:{{ default(user,  \"@me\") }} is:pr" {
+		t.Fatalf("expected only whitespace outside actions to change, got %s", formatted.Searches[2].Query)
+	}
+	if formatted.Templates["repos"].Query != "is:pr ({{ join(repos, \"OR\") }})" {
+		t.Fatalf("unexpected formatted template: %s", formatted.Templates["repos"].Query)
+	}
+
+	want := []string{"Messy", "Templated", "template repos"}
+	if len(changed) != len(want) {
+		t.Fatalf("expected changes %v, got %v", want, changed)
+	}
+	for i := range want {
+		if changed[i] != want[i] {
+			t.Fatalf("expected changes %v, got %v", want, changed)
+		}
+	}
+
+	if cfg.Searches[0].Query != "state:open   is:pr  archived:false" {
+		t.Fatalf("expected input config to be left untouched")
+	}
+}
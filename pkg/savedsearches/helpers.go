@@ -0,0 +1,244 @@
+package savedsearches
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// dateLayout matches the date format GitHub search qualifiers expect.
+const dateLayout = "2006-01-02"
+
+// queryList is a list of query terms. It prints space separated, so list
+// helpers can be used directly in a template or passed to other helpers.
+type queryList []string
+
+func (l queryList) String() string { return strings.Join(l, " ") }
+
+// templateFuncs returns the helpers available to every template. The date
+// helpers read today's date from clock.
+func templateFuncs(clock Clock) template.FuncMap {
+	return template.FuncMap{
+		"default": defaultHelper,
+		"join":    joinHelper,
+		"quote":   quoteHelper,
+		"prefix":  prefixHelper,
+		"anyOf":   groupHelper("OR"),
+		"allOf":   groupHelper("AND"),
+		"lower":   lowerHelper,
+		"exclude": excludeHelper,
+		"date":    dateHelper(clock),
+		"ago":     agoHelper(clock),
+		"env":     envHelper,
+	}
+}
+
+// defaultHelper returns fallback when value is nil or empty.
+func defaultHelper(value, fallback any) any {
+	switch v := value.(type) {
+	case nil:
+		return fallback
+	case string:
+		if v == "" {
+			return fallback
+		}
+	case []any:
+		if len(v) == 0 {
+			return fallback
+		}
+	case queryList:
+		if len(v) == 0 {
+			return fallback
+		}
+	}
+	return value
+}
+
+// joinHelper joins list items with sep, adding spaces around it.
+func joinHelper(value, sep any) string {
+	return strings.Join(toTerms(value), " "+fmt.Sprint(sep)+" ")
+}
+
+// quoteHelper wraps values containing whitespace in double quotes.
+func quoteHelper(value any) any {
+	return mapTerms(value, func(s string) string {
+		if strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) && len(s) > 1 {
+			return s
+		}
+		if strings.IndexFunc(s, unicode.IsSpace) < 0 {
+			return s
+		}
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	})
+}
+
+// prefixHelper adds prefix to each value that doesn't already have it.
+func prefixHelper(value, prefix any) any {
+	p := fmt.Sprint(prefix)
+	return mapTerms(value, func(s string) string {
+		if strings.HasPrefix(s, p) {
+			return s
+		}
+		return p + s
+	})
+}
+
+// groupHelper builds a parenthesised group joined by op. Empty values are
+// skipped and a single value is returned without parentheses.
+func groupHelper(op string) func(values ...any) string {
+	return func(values ...any) string {
+		var terms []string
+		for _, v := range values {
+			for _, t := range toTerms(v) {
+				if t != "" {
+					terms = append(terms, t)
+				}
+			}
+		}
+		switch len(terms) {
+		case 0:
+			return ""
+		case 1:
+			return terms[0]
+		default:
+			return "(" + strings.Join(terms, " "+op+" ") + ")"
+		}
+	}
+}
+
+// lowerHelper lower-cases each value.
+func lowerHelper(value any) any {
+	return mapTerms(value, strings.ToLower)
+}
+
+// excludeHelper negates each value.
+func excludeHelper(value any) any {
+	return mapTerms(value, func(s string) string {
+		if strings.HasPrefix(s, "-") {
+			return s
+		}
+		return "-" + s
+	})
+}
+
+// dateHelper returns today's date, optionally shifted by an offset such as
+// "-7d" or "+2w".
+func dateHelper(clock Clock) func(offset ...any) (string, error) {
+	return func(offset ...any) (string, error) {
+		now := clock.Now()
+		if len(offset) == 0 {
+			return now.Format(dateLayout), nil
+		}
+		shifted, err := shiftDate(now, fmt.Sprint(offset[0]), 1)
+		if err != nil {
+			return "", err
+		}
+		return shifted.Format(dateLayout), nil
+	}
+}
+
+// agoHelper returns the date the given duration before today, e.g. "7d".
+func agoHelper(clock Clock) func(duration any) (string, error) {
+	return func(duration any) (string, error) {
+		shifted, err := shiftDate(clock.Now(), fmt.Sprint(duration), -1)
+		if err != nil {
+			return "", err
+		}
+		return shifted.Format(dateLayout), nil
+	}
+}
+
+// envHelper reads a SAVED_SEARCH_VAR_ environment variable, with an optional
+// fallback. The prefix may be left off the name. Other variables, such as
+// GH_TOKEN, are out of reach so a shared config can't leak them into a query.
+func envHelper(name string, fallback ...any) string {
+	if !strings.HasPrefix(name, envVarPrefix) {
+		name = envVarPrefix + name
+	}
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	if len(fallback) > 0 && fallback[0] != nil {
+		return fmt.Sprint(fallback[0])
+	}
+	return ""
+}
+
+// shiftDate moves t by a relative amount like "7d", "-2w", "+3m" or "1y".
+// Units are days, weeks, months and years; sign multiplies the amount.
+func shiftDate(t time.Time, rel string, sign int) (time.Time, error) {
+	rel = strings.TrimSpace(rel)
+	if rel == "" {
+		return t, nil
+	}
+	if strings.HasPrefix(rel, "+") {
+		rel = rel[1:]
+	} else if strings.HasPrefix(rel, "-") {
+		rel = rel[1:]
+		sign = -sign
+	}
+	if len(rel) < 2 {
+		return t, fmt.Errorf("invalid relative date %q", rel)
+	}
+
+	n, err := strconv.Atoi(rel[:len(rel)-1])
+	if err != nil {
+		return t, fmt.Errorf("invalid relative date %q", rel)
+	}
+	n *= sign
+
+	switch rel[len(rel)-1] {
+	case 'd':
+		return t.AddDate(0, 0, n), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*n), nil
+	case 'm':
+		return t.AddDate(0, n, 0), nil
+	case 'y':
+		return t.AddDate(n, 0, 0), nil
+	default:
+		return t, fmt.Errorf("invalid relative date %q: unit must be d, w, m or y", rel)
+	}
+}
+
+// toTerms converts a template value into a list of strings.
+func toTerms(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case queryList:
+		return v
+	case []string:
+		return v
+	case []any:
+		terms := make([]string, 0, len(v))
+		for _, item := range v {
+			terms = append(terms, fmt.Sprint(item))
+		}
+		return terms
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// mapTerms applies fn to a single value or to each item of a list, keeping
+// the shape of the input.
+func mapTerms(value any, fn func(string) string) any {
+	switch value.(type) {
+	case nil:
+		return nil
+	case queryList, []string, []any:
+		terms := toTerms(value)
+		out := make(queryList, len(terms))
+		for i, t := range terms {
+			out[i] = fn(t)
+		}
+		return out
+	default:
+		return fn(fmt.Sprint(value))
+	}
+}
@@ -0,0 +1,61 @@
+package savedsearches
+
+import "testing"
+
+func TestTemplateHelpers(t *testing.T) {
+	clock := newFakeClock()
+	t.Setenv("SAVED_SEARCH_VAR_ORG", "Kong")
+	t.Setenv("SAVED_SEARCH_SECRET", "hunter2")
+
+	vars := map[string]any{
+		"repos":  []any{"a/b", "repo:c/d"},
+		"labels": []any{"label:bug", "label:wontfix"},
+		"title":  "needs review",
+		"Team":   "Platform",
+	}
+
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"quote value", `{{ quote(title) }}`, `"needs review"`},
+		{"quote plain", `{{ quote("bug") }}`, `bug`},
+		{"quote list", `{{ quote(list) }}`, `"a b" c`},
+		{"prefix", `{{ join(prefix(repos, "repo:"), "OR") }}`, "repo:a/b OR repo:c/d"},
+		{"anyOf", `{{ anyOf(prefix(repos, "repo:")) }}`, "(repo:a/b OR repo:c/d)"},
+		{"anyOf single", `{{ anyOf("repo:a/b") }}`, "repo:a/b"},
+		{"allOf", `{{ allOf("is:pr", missing, "draft:false") }}`, "(is:pr AND draft:false)"},
+		{"builtin and", `{{ if and title Team }}both{{ end }}`, "both"},
+		{"builtin or", `{{ if or missing Team }}either{{ end }}`, "either"},
+		{"lower", `team:{{ lower(Team) }}`, "team:platform"},
+		{"exclude", `{{ exclude(labels) }}`, "-label:bug -label:wontfix"},
+		{"date", `{{ date() }}`, "2026-10-16"},
+		{"date offset", `{{ date("+1w") }}`, "2026-10-23"},
+		{"ago", `updated:>{{ ago("7d") }}`, "updated:>2026-10-09"},
+		{"ago months", `{{ ago("1m") }}`, "2026-09-16"},
+		{"env", `org:{{ env("ORG") }}`, "org:Kong"},
+		{"env prefixed", `org:{{ env("SAVED_SEARCH_VAR_ORG") }}`, "org:Kong"},
+		{"env fallback", `org:{{ env("MISSING", "mine") }}`, "org:mine"},
+		{"env outside prefix", `{{ env("SAVED_SEARCH_SECRET", "none") }}`, "none"},
+	}
+
+	vars["list"] = []string{"a b", "c"}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := renderTemplate("test", tc.in, vars, clock, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAgoInvalidUnit(t *testing.T) {
+	if _, err := renderTemplate("test", `{{ ago("7x") }}`, nil, newFakeClock(), nil); err == nil {
+		t.Fatalf("expected error for invalid unit")
+	}
+}
@@ -0,0 +1,130 @@
+package savedsearches
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ImportFormats lists the file formats supported by ReadImport. They match
+// the json and csv output of Export.
+var ImportFormats = []string{"json", "csv"}
+
+// ReadImport reads searches written by Export. A section header entry is
+// inserted whenever the section changes.
+func ReadImport(r io.Reader, format string) ([]SearchDefinition, error) {
+	var rows []exportedSearch
+	switch format {
+	case "json":
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("parse import json: %w", err)
+		}
+	case "csv":
+		records, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("parse import csv: %w", err)
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		cols := map[string]int{}
+		for i, name := range records[0] {
+			cols[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		nameCol, ok := cols["name"]
+		if !ok {
+			return nil, errors.New("parse import csv: missing name column")
+		}
+		queryCol, ok := cols["query"]
+		if !ok {
+			return nil, errors.New("parse import csv: missing query column")
+		}
+		sectionCol, hasSection := cols["section"]
+		for _, rec := range records[1:] {
+			row := exportedSearch{Name: rec[nameCol], Query: rec[queryCol]}
+			if hasSection {
+				row.Section = rec[sectionCol]
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return nil, fmt.Errorf("unknown import format %q (want one of %s)", format, strings.Join(ImportFormats, ", "))
+	}
+
+	var defs []SearchDefinition
+	section := ""
+	for _, row := range rows {
+		if row.Section != section {
+			section = row.Section
+			if section != "" {
+				defs = append(defs, SearchDefinition{Section: section})
+			}
+		}
+		defs = append(defs, SearchDefinition{Name: row.Name, Query: row.Query})
+	}
+	return defs, nil
+}
+
+// ListLiveSearches returns the saved searches currently on GitHub as config
+// entries, turning "== NAME ==" headers back into sections. The client must
+// implement SavedSearchLister.
+func ListLiveSearches(ctx context.Context, client Client) ([]SearchDefinition, error) {
+	lister, ok := client.(SavedSearchLister)
+	if !ok {
+		return nil, errors.New("client cannot list saved searches; import from a file instead")
+	}
+	live, err := lister.ListSavedSearches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list saved searches: %w", err)
+	}
+
+	defs := make([]SearchDefinition, 0, len(live))
+	for _, s := range live {
+		if s.Query == "" && strings.HasPrefix(s.Name, "== ") && strings.HasSuffix(s.Name, " ==") {
+			defs = append(defs, SearchDefinition{ID: s.ID, Section: strings.TrimSuffix(strings.TrimPrefix(s.Name, "== "), " ==")})
+			continue
+		}
+		defs = append(defs, SearchDefinition{ID: s.ID, Name: s.Name, Query: s.Query})
+	}
+	return defs, nil
+}
+
+// ImportSearches appends defs to the config, skipping entries whose ID or
+// display name is already configured. It returns the names it added.
+func ImportSearches(cfg Config, defs []SearchDefinition) (Config, []string) {
+	ids := map[string]bool{}
+	names := map[string]bool{}
+	for _, search := range cfg.Searches {
+		if search.ID != "" {
+			ids[search.ID] = true
+		}
+		for _, id := range search.IDs {
+			ids[id] = true
+		}
+		if name, err := displayName(search); err == nil {
+			names[name] = true
+		}
+		if search.IsExpanded() {
+			expansions, _ := ExpandSearch(WithGlobalVars(search, cfg.GlobalVars(nil)))
+			for _, exp := range expansions {
+				names[exp.Definition.Name] = true
+			}
+		}
+	}
+
+	var added []string
+	for _, def := range defs {
+		name, err := displayName(def)
+		if err != nil || names[name] || (def.ID != "" && ids[def.ID]) {
+			continue
+		}
+		names[name] = true
+		cfg.Searches = append(cfg.Searches, def)
+		added = append(added, name)
+	}
+	return cfg, added
+}
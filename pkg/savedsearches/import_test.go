@@ -0,0 +1,100 @@
+package savedsearches
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadImportJSON(t *testing.T) {
+	in := `[
+  {"name": "Mine", "section": "Team", "query": "is:pr author:@me", "url": "https://github.com/issues?q=x"},
+  {"name": "Theirs", "section": "Team", "query": "is:pr"},
+  {"name": "Loose", "query": "is:issue"}
+]`
+	defs, err := ReadImport(strings.NewReader(in), "json")
+	if err != nil {
+		t.Fatalf("read import: %v", err)
+	}
+
+	want := []SearchDefinition{
+		{Section: "Team"},
+		{Name: "Mine", Query: "is:pr author:@me"},
+		{Name: "Theirs", Query: "is:pr"},
+		{Name: "Loose", Query: "is:issue"},
+	}
+	if !reflect.DeepEqual(defs, want) {
+		t.Fatalf("unexpected defs:\n got %+v\nwant %+v", defs, want)
+	}
+}
+
+func TestReadImportCSV(t *testing.T) {
+	in := "name,section,query,url\nMine,,is:pr author:@me,https://github.com/issues?q=x\n\"Bugs, all\",Triage,is:issue label:bug,\n"
+	defs, err := ReadImport(strings.NewReader(in), "csv")
+	if err != nil {
+		t.Fatalf("read import: %v", err)
+	}
+
+	want := []SearchDefinition{
+		{Name: "Mine", Query: "is:pr author:@me"},
+		{Section: "Triage"},
+		{Name: "Bugs, all", Query: "is:issue label:bug"},
+	}
+	if !reflect.DeepEqual(defs, want) {
+		t.Fatalf("unexpected defs:\n got %+v\nwant %+v", defs, want)
+	}
+
+	if _, err := ReadImport(strings.NewReader("title,query\n"), "csv"); err == nil {
+		t.Fatalf("expected error for csv without a name column")
+	}
+	if _, err := ReadImport(strings.NewReader(""), "yaml"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestImportSearchesSkipsExisting(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{
+		{Section: "Team"},
+		{Name: "Mine", Query: "is:pr"},
+		{Name: "Work from {{ user }}", Template: "work", Matrix: map[string][]any{"user": {"alice"}}, IDs: map[string]string{"Work from alice": "SSC_alice"}},
+	}}
+
+	cfg, added := ImportSearches(cfg, []SearchDefinition{
+		{Section: "Team"},
+		{Name: "Mine", Query: "is:pr author:@me"},
+		{Name: "Work from alice", Query: "author:alice"},
+		{ID: "SSC_alice", Name: "Renamed", Query: "author:alice"},
+		{Name: "New", Query: "is:issue"},
+	})
+
+	if !reflect.DeepEqual(added, []string{"New"}) {
+		t.Fatalf("expected only New to be added, got %v", added)
+	}
+	if len(cfg.Searches) != 4 || cfg.Searches[3].Name != "New" {
+		t.Fatalf("unexpected searches: %+v", cfg.Searches)
+	}
+}
+
+func TestListLiveSearches(t *testing.T) {
+	client := &listingClient{live: []SavedSearch{
+		{ID: "SSC_team", Name: "== Team =="},
+		{ID: "SSC_mine", Name: "Mine", Query: "is:pr"},
+	}}
+	defs, err := ListLiveSearches(context.Background(), client)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+
+	want := []SearchDefinition{
+		{ID: "SSC_team", Section: "Team"},
+		{ID: "SSC_mine", Name: "Mine", Query: "is:pr"},
+	}
+	if !reflect.DeepEqual(defs, want) {
+		t.Fatalf("unexpected defs:\n got %+v\nwant %+v", defs, want)
+	}
+
+	if _, err := ListLiveSearches(context.Background(), &stubClient{}); err == nil {
+		t.Fatalf("expected error for a client that cannot list")
+	}
+}
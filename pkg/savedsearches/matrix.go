@@ -0,0 +1,150 @@
+package savedsearches
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Expansion is a single search produced from a matrix or for_each entry.
+// Key identifies the combination of values, so its ID survives changes to
+// the name. LegacyKey is set when the ID was found under the rendered name,
+// which is how earlier releases keyed ids.
+type Expansion struct {
+	Key        string
+	LegacyKey  string
+	Definition SearchDefinition
+}
+
+// IsExpanded reports whether the definition generates multiple searches.
+func (def SearchDefinition) IsExpanded() bool {
+	return len(def.Matrix) > 0 || len(def.ForEach) > 0
+}
+
+// ExpandSearch renders each combination of a matrix or for_each entry into
+// its own definition. The combination's values are used as the key for
+// tracking IDs.
+func ExpandSearch(def SearchDefinition) ([]Expansion, error) {
+	return expandSearchAt(realClock{}, def)
+}
+
+// expandSearchAt is ExpandSearch with the date helpers reading clock.
+func expandSearchAt(clock Clock, def SearchDefinition) ([]Expansion, error) {
+	if len(def.Matrix) > 0 && len(def.ForEach) > 0 {
+		return nil, errors.New("use either matrix or for_each, not both")
+	}
+	if def.Name == "" {
+		return nil, errors.New("expanded search entry missing name")
+	}
+
+	var combos []map[string]any
+	if len(def.ForEach) > 0 {
+		combos = def.ForEach
+	} else {
+		// An empty dimension would expand to nothing and delete every
+		// search the entry tracks, which is never what a typo or an empty
+		// var meant.
+		for key, values := range def.Matrix {
+			if len(values) == 0 {
+				return nil, fmt.Errorf("matrix %q has no values", key)
+			}
+		}
+		combos = matrixCombinations(def.Matrix)
+	}
+
+	seen := map[string]bool{}
+	seenKeys := map[string]bool{}
+	expansions := make([]Expansion, 0, len(combos))
+	for _, combo := range combos {
+		key := comboKey(combo)
+		if seenKeys[key] {
+			return nil, fmt.Errorf("for_each entry %q is listed more than once", key)
+		}
+		seenKeys[key] = true
+
+		vars := make(map[string]any, len(def.Vars)+len(combo))
+		for k, v := range def.Vars {
+			vars[k] = v
+		}
+		for k, v := range combo {
+			vars[k] = v
+		}
+
+		name, err := renderTemplate("name", def.Name, vars, clock, nil)
+		if err != nil {
+			return nil, err
+		}
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			return nil, fmt.Errorf("expanded name %q is not unique; reference a matrix var in the name", name)
+		}
+		seen[name] = true
+
+		id, legacy := def.IDs[key], ""
+		if id == "" && def.IDs[name] != "" {
+			id, legacy = def.IDs[name], name
+		}
+
+		expansions = append(expansions, Expansion{
+			Key:       key,
+			LegacyKey: legacy,
+			Definition: SearchDefinition{
+				ID:       id,
+				Name:     name,
+				Query:    def.Query,
+				Section:  def.Section,
+				Template: def.Template,
+				Vars:     vars,
+				Remove:   def.Remove,
+
+				WarnAbove: def.WarnAbove,
+			},
+		})
+	}
+
+	return expansions, nil
+}
+
+// comboKey identifies a combination by its values, e.g. "repo=a, user=alice".
+func comboKey(combo map[string]any) string {
+	keys := make([]string, 0, len(combo))
+	for k := range combo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, combo[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// matrixCombinations returns the cartesian product of the matrix values,
+// ordered by key so the expansion is stable between runs.
+func matrixCombinations(matrix map[string][]any) []map[string]any {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	combos := []map[string]any{{}}
+	for _, key := range keys {
+		var next []map[string]any
+		for _, combo := range combos {
+			for _, val := range matrix[key] {
+				c := make(map[string]any, len(combo)+1)
+				for k, v := range combo {
+					c[k] = v
+				}
+				c[key] = val
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+
+	return combos
+}
@@ -0,0 +1,124 @@
+package savedsearches
+
+import "testing"
+
+func TestExpandSearchMatrix(t *testing.T) {
+	expansions, err := ExpandSearch(SearchDefinition{
+		Name:     "Work from {{ user }} in {{ repo }}",
+		Template: "recent",
+		Vars:     map[string]any{"time": "7d"},
+		Matrix: map[string][]any{
+			"user": {"alice", "bob"},
+			"repo": {"a", "b"},
+		},
+		IDs: map[string]string{"repo=a, user=alice": "SSC_1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct{ key, name string }{
+		{"repo=a, user=alice", "Work from alice in a"},
+		{"repo=a, user=bob", "Work from bob in a"},
+		{"repo=b, user=alice", "Work from alice in b"},
+		{"repo=b, user=bob", "Work from bob in b"},
+	}
+	if len(expansions) != len(want) {
+		t.Fatalf("expected %d expansions, got %d", len(want), len(expansions))
+	}
+	for i, w := range want {
+		if expansions[i].Key != w.key || expansions[i].Definition.Name != w.name {
+			t.Fatalf("index %d: expected %s (%s), got %+v", i, w.name, w.key, expansions[i])
+		}
+		if expansions[i].Definition.Vars["time"] != "7d" {
+			t.Fatalf("expected shared vars to be kept, got %+v", expansions[i].Definition.Vars)
+		}
+	}
+	if expansions[0].Definition.ID != "SSC_1" || expansions[0].LegacyKey != "" {
+		t.Fatalf("expected tracked id, got %+v", expansions[0])
+	}
+}
+
+func TestExpandSearchLegacyNameKey(t *testing.T) {
+	expansions, err := ExpandSearch(SearchDefinition{
+		Name:   "Work from {{ user }}",
+		Query:  "state:open",
+		Matrix: map[string][]any{"user": {"alice"}},
+		IDs:    map[string]string{"Work from alice": "SSC_1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := expansions[0]; exp.Key != "user=alice" || exp.LegacyKey != "Work from alice" || exp.Definition.ID != "SSC_1" {
+		t.Fatalf("expected the id found under the rendered name, got %+v", exp)
+	}
+}
+
+func TestExpandSearchKeyNotIdentifier(t *testing.T) {
+	expansions, err := ExpandSearch(SearchDefinition{
+		Name:   `Open in {{ index . "repo-name" }}`,
+		Query:  "state:open",
+		Matrix: map[string][]any{"repo-name": {"a/b"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expansions[0].Definition.Name != "Open in a/b" || expansions[0].Key != "repo-name=a/b" {
+		t.Fatalf("unexpected expansion: %+v", expansions[0])
+	}
+}
+
+func TestExpandSearchForEach(t *testing.T) {
+	expansions, err := ExpandSearch(SearchDefinition{
+		Name:  "Work from {{ user }}",
+		Query: "state:open",
+		Vars:  map[string]any{"user": "default"},
+		ForEach: []map[string]any{
+			{"user": "alice"},
+			{"user": "bob"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(expansions) != 2 || expansions[1].Key != "user=bob" || expansions[1].Definition.Name != "Work from bob" {
+		t.Fatalf("unexpected expansions: %+v", expansions)
+	}
+	if expansions[1].Definition.Vars["user"] != "bob" {
+		t.Fatalf("expected for_each vars to override entry vars")
+	}
+}
+
+func TestExpandSearchDuplicateNames(t *testing.T) {
+	_, err := ExpandSearch(SearchDefinition{
+		Name:   "Static",
+		Query:  "state:open",
+		Matrix: map[string][]any{"user": {"alice", "bob"}},
+	})
+	if err == nil {
+		t.Fatalf("expected error for duplicate names")
+	}
+}
+
+func TestExpandSearchDuplicateForEach(t *testing.T) {
+	_, err := ExpandSearch(SearchDefinition{
+		Name:    "Work from {{ user }} {{ n }}",
+		Query:   "state:open",
+		ForEach: []map[string]any{{"user": "alice"}, {"user": "alice"}},
+	})
+	if err == nil {
+		t.Fatalf("expected error for a repeated for_each entry")
+	}
+}
+
+func TestExpandSearchEmptyMatrix(t *testing.T) {
+	_, err := ExpandSearch(SearchDefinition{
+		Name:   "Work from {{ user }} in {{ repo }}",
+		Query:  "state:open",
+		Matrix: map[string][]any{"user": {"alice"}, "repo": {}},
+		IDs:    map[string]string{"repo=a, user=alice": "SSC_1"},
+	})
+	if err == nil || err.Error() != `matrix "repo" has no values` {
+		t.Fatalf("expected error for empty dimension, got %v", err)
+	}
+}
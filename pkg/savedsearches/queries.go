@@ -0,0 +1,380 @@
+package savedsearches
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrPersistedQueryNotFound is returned when GitHub no longer knows a
+// persisted query ID, which happens when it ships a new web UI.
+var ErrPersistedQueryNotFound = errors.New("persisted query not found")
+
+// PersistedQueries are the IDs of the web UI's persisted GraphQL queries
+// for saved searches. Empty fields fall back to the built-in IDs. There is
+// no built-in List: it has to be discovered or configured before the
+// client can read the current searches.
+type PersistedQueries struct {
+	Create string `yaml:"create,omitempty" json:"create,omitempty"`
+	Update string `yaml:"update,omitempty" json:"update,omitempty"`
+	Delete string `yaml:"delete,omitempty" json:"delete,omitempty"`
+	List   string `yaml:"list,omitempty" json:"list,omitempty"`
+}
+
+// queryOperations are the operations PersistedQueries holds IDs for.
+var queryOperations = []string{"create", "update", "delete", "list"}
+
+// persistedQueryEnv names the environment variables that override each ID.
+var persistedQueryEnv = map[string]string{
+	"create": "GH_SAVED_ISSUES_QUERY_CREATE",
+	"update": "GH_SAVED_ISSUES_QUERY_UPDATE",
+	"delete": "GH_SAVED_ISSUES_QUERY_DELETE",
+	"list":   "GH_SAVED_ISSUES_QUERY_LIST",
+}
+
+// DefaultPersistedQueries returns the IDs built into this release.
+func DefaultPersistedQueries() PersistedQueries {
+	return PersistedQueries{Create: createPersistedID, Update: updatePersistedID, Delete: deletePersistedID}
+}
+
+// ID returns the ID for an operation: create, update, delete or list.
+func (q PersistedQueries) ID(operation string) string {
+	switch operation {
+	case "create":
+		return q.Create
+	case "update":
+		return q.Update
+	case "delete":
+		return q.Delete
+	case "list":
+		return q.List
+	}
+	return ""
+}
+
+func (q *PersistedQueries) set(operation, id string) {
+	switch operation {
+	case "create":
+		q.Create = id
+	case "update":
+		q.Update = id
+	case "delete":
+		q.Delete = id
+	case "list":
+		q.List = id
+	}
+}
+
+// Merge returns q with the non-empty IDs of other on top.
+func (q PersistedQueries) Merge(other PersistedQueries) PersistedQueries {
+	for _, op := range queryOperations {
+		if id := other.ID(op); id != "" {
+			q.set(op, id)
+		}
+	}
+	return q
+}
+
+// complete reports whether every operation has an ID.
+func (q PersistedQueries) complete() bool {
+	return q.Create != "" && q.Update != "" && q.Delete != "" && q.List != ""
+}
+
+// EnvPersistedQueries reads the GH_SAVED_ISSUES_QUERY_<OPERATION> overrides.
+func EnvPersistedQueries() PersistedQueries {
+	var q PersistedQueries
+	for _, op := range queryOperations {
+		q.set(op, os.Getenv(persistedQueryEnv[op]))
+	}
+	return q
+}
+
+// ResolvePersistedQueries picks the IDs a client for host uses: the
+// built-in ones, overridden by discovered IDs in the cache, then the
+// config's persisted_queries, then the environment. If the cache can't be
+// read, it returns the IDs from the other sources along with the error, so
+// callers can warn and carry on.
+func ResolvePersistedQueries(cfg Config, host string) (PersistedQueries, error) {
+	queries := DefaultPersistedQueries()
+	overrides := cfg.PersistedQueries.Merge(EnvPersistedQueries())
+
+	path, err := ResolveQueryCachePath()
+	if err != nil {
+		return queries.Merge(overrides), err
+	}
+	cache, err := LoadQueryCache(path)
+	if err != nil {
+		return queries.Merge(overrides), err
+	}
+	if cached, ok := cache.Lookup(host); ok {
+		queries = queries.Merge(cached.PersistedQueries)
+	}
+
+	return queries.Merge(overrides), nil
+}
+
+// queryCacheVersion is bumped when the cache format changes.
+const queryCacheVersion = 1
+
+// QueryCache holds discovered persisted query IDs by host.
+type QueryCache struct {
+	Version int                      `json:"version"`
+	Hosts   map[string]CachedQueries `json:"hosts"`
+}
+
+// CachedQueries are the IDs discovered for one host. Builtin stamps the
+// built-in IDs at the time, so a release with newer built-in IDs ignores
+// the entry; Bundle stamps the page or bundle they were found in.
+type CachedQueries struct {
+	PersistedQueries
+	Builtin      string    `json:"builtin"`
+	Bundle       string    `json:"bundle"`
+	Source       string    `json:"source"`
+	DiscoveredAt time.Time `json:"discovered_at"`
+}
+
+// builtinStamp identifies the built-in IDs of this release.
+func builtinStamp() string {
+	q := DefaultPersistedQueries()
+	sum := sha256.Sum256([]byte(q.Create + q.Update + q.Delete))
+	return hex.EncodeToString(sum[:6])
+}
+
+// NewCachedQueries stamps discovered IDs for the cache.
+func NewCachedQueries(queries PersistedQueries, source string, content []byte) CachedQueries {
+	sum := sha256.Sum256(content)
+	return CachedQueries{
+		PersistedQueries: queries,
+		Builtin:          builtinStamp(),
+		Bundle:           hex.EncodeToString(sum[:6]),
+		Source:           source,
+		DiscoveredAt:     time.Now().UTC(),
+	}
+}
+
+// Lookup returns the cached IDs for host, unless they were discovered
+// under different built-in IDs.
+func (c QueryCache) Lookup(host string) (CachedQueries, bool) {
+	cached, ok := c.Hosts[NormalizeHost(host)]
+	if !ok || cached.Builtin != builtinStamp() {
+		return CachedQueries{}, false
+	}
+	return cached, true
+}
+
+// Store records discovered IDs for host.
+func (c *QueryCache) Store(host string, cached CachedQueries) {
+	if c.Hosts == nil {
+		c.Hosts = map[string]CachedQueries{}
+	}
+	c.Hosts[NormalizeHost(host)] = cached
+}
+
+// ResolveQueryCachePath returns $XDG_CACHE_HOME/gh-saved-issues/queries.json,
+// or the same under ~/.cache.
+func ResolveQueryCachePath() (string, error) {
+	if xdgCache := os.Getenv("XDG_CACHE_HOME"); xdgCache != "" {
+		return filepath.Join(xdgCache, "gh-saved-issues", "queries.json"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locate home dir: %w", err)
+	}
+
+	return filepath.Join(home, ".cache", "gh-saved-issues", "queries.json"), nil
+}
+
+// LoadQueryCache reads the cache. A missing file, or one written in another
+// format version, is an empty cache.
+func LoadQueryCache(path string) (QueryCache, error) {
+	cache := QueryCache{Version: queryCacheVersion}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, fmt.Errorf("read query cache: %w", err)
+	}
+
+	var loaded QueryCache
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		return cache, fmt.Errorf("parse query cache: %w", err)
+	}
+	if loaded.Version != queryCacheVersion {
+		return cache, nil
+	}
+	return loaded, nil
+}
+
+// SaveQueryCache writes the cache.
+func SaveQueryCache(path string, cache QueryCache) error {
+	cache.Version = queryCacheVersion
+	out, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal query cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure cache dir: %w", err)
+	}
+
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		return fmt.Errorf("write query cache: %w", err)
+	}
+
+	return nil
+}
+
+var (
+	// persistedIDPattern matches a Relay persisted query ID in a bundle,
+	// quoted as JSON or as a JavaScript object key.
+	persistedIDPattern = regexp.MustCompile(`"?\bid"?\s*:\s*"([0-9a-f]{32})"`)
+	// operationNamePattern matches the operation name that follows an ID in
+	// the same request parameters.
+	operationNamePattern = regexp.MustCompile(`"?\bname"?\s*:\s*"(\w+)"`)
+)
+
+// DiscoverPersistedQueries extracts the saved search query IDs from the
+// source of a GitHub page or JavaScript bundle. It looks for persisted
+// query parameters whose operation name mentions a shortcut or saved
+// search along with create, update or delete, or that is a query.
+func DiscoverPersistedQueries(src []byte) PersistedQueries {
+	var q PersistedQueries
+	for _, m := range persistedIDPattern.FindAllSubmatchIndex(src, -1) {
+		end := min(m[1]+300, len(src))
+		name := operationNamePattern.FindSubmatch(src[m[1]:end])
+		if name == nil {
+			continue
+		}
+
+		op := operationFor(strings.ToLower(string(name[1])))
+		if op != "" && q.ID(op) == "" {
+			q.set(op, string(src[m[2]:m[3]]))
+		}
+	}
+	return q
+}
+
+// operationFor maps a lower-cased GraphQL operation name to create, update
+// or delete for mutations, or list for queries, or "" if it isn't about
+// saved searches.
+func operationFor(name string) string {
+	if !strings.Contains(name, "shortcut") && !strings.Contains(name, "savedsearch") {
+		return ""
+	}
+	for _, op := range []string{"create", "update", "delete"} {
+		if strings.Contains(name, op) {
+			return op
+		}
+	}
+	if strings.HasSuffix(name, "query") {
+		return "list"
+	}
+	return ""
+}
+
+// scriptSrcPattern matches the bundles a page loads.
+var scriptSrcPattern = regexp.MustCompile(`<script[^>]+src="([^"]+\.js)"`)
+
+// maxDiscoveryScripts bounds how many bundles DiscoverQueries fetches.
+const maxDiscoveryScripts = 100
+
+// DiscoverQueries fetches a GitHub page with the session cookie and
+// extracts the saved search query IDs from it and the bundles it loads. It
+// returns what it found, with all the content it searched for stamping.
+func (c *GraphQLClient) DiscoverQueries(ctx context.Context, pageURL string) (PersistedQueries, []byte, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return PersistedQueries{}, nil, fmt.Errorf("parse page URL: %w", err)
+	}
+
+	body, err := c.fetch(ctx, page, true)
+	if err != nil {
+		return PersistedQueries{}, nil, err
+	}
+	content := body
+	queries := DiscoverPersistedQueries(body)
+
+	scripts := scriptSrcPattern.FindAllSubmatch(body, maxDiscoveryScripts)
+	for _, m := range scripts {
+		if queries.complete() {
+			break
+		}
+		src, err := page.Parse(string(m[1]))
+		if err != nil {
+			continue
+		}
+		bundle, err := c.fetch(ctx, src, false)
+		if err != nil {
+			return queries, content, err
+		}
+		content = append(content, bundle...)
+		queries = queries.Merge(DiscoverPersistedQueries(bundle))
+	}
+
+	if queries == (PersistedQueries{}) {
+		return queries, content, fmt.Errorf("no saved search queries found in %s or the %d scripts it loads", pageURL, len(scripts))
+	}
+	return queries, content, nil
+}
+
+// fetch GETs a page or bundle. The cookie is only sent to the client's own
+// host.
+func (c *GraphQLClient) fetch(ctx context.Context, u *url.URL, withCookie bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	if withCookie && c.cookie != "" && u.Hostname() == c.Host() {
+		req.Header.Set("Cookie", c.cookie)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logRequest(req, 0, nil, 0, time.Since(start), err)
+		return nil, fmt.Errorf("get %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	var body bytes.Buffer
+	_, err = io.Copy(&body, io.LimitReader(resp.Body, 20<<20))
+	c.logRequest(req, 0, resp, body.Len(), time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", u, err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("get %s: status %d", u, resp.StatusCode)
+	}
+	return body.Bytes(), nil
+}
+
+// isPersistedQueryNotFound reports whether GitHub rejected a request
+// because it doesn't know the persisted query.
+func isPersistedQueryNotFound(err error) bool {
+	var gqlErr *GraphQLError
+	if !errors.As(err, &gqlErr) {
+		return false
+	}
+	for _, msg := range gqlErr.Messages {
+		msg = strings.ToLower(msg)
+		if strings.Contains(msg, "persisted") || strings.Contains(msg, "query not found") {
+			return true
+		}
+	}
+	return false
+}
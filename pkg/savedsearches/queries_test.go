@@ -0,0 +1,168 @@
+package savedsearches
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const bundleJS = `
+const a={id:"11111111111111111111111111111111",metadata:{},name:"createShortcutMutation",operationKind:"mutation"};
+const b={"id":"22222222222222222222222222222222","metadata":{},"name":"updateDashboardShortcutMutation"};
+const c={id:"33333333333333333333333333333333",name:"RepositoryIssuesQuery"};
+const d={id:"55555555555555555555555555555555",metadata:{},name:"DashboardShortcutsQuery",operationKind:"query"};
+`
+
+func TestDiscoverPersistedQueries(t *testing.T) {
+	got := DiscoverPersistedQueries([]byte(bundleJS))
+	want := PersistedQueries{Create: "11111111111111111111111111111111", Update: "22222222222222222222222222222222", List: "55555555555555555555555555555555"}
+	if got != want {
+		t.Fatalf("unexpected queries: %+v", got)
+	}
+}
+
+func TestResolvePersistedQueries(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("GH_SAVED_ISSUES_QUERY_DELETE", "env-delete")
+
+	path, err := ResolveQueryCachePath()
+	if err != nil {
+		t.Fatalf("resolve cache path: %v", err)
+	}
+	var cache QueryCache
+	cache.Store("GHE.example.com", NewCachedQueries(PersistedQueries{Create: "cached-create", Update: "cached-update"}, "test", []byte(bundleJS)))
+	if err := SaveQueryCache(path, cache); err != nil {
+		t.Fatalf("save cache: %v", err)
+	}
+
+	cfg := Config{PersistedQueries: PersistedQueries{Update: "config-update"}}
+	got, err := ResolvePersistedQueries(cfg, "ghe.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (PersistedQueries{Create: "cached-create", Update: "config-update", Delete: "env-delete"}); got != want {
+		t.Fatalf("unexpected queries: %+v", got)
+	}
+
+	got, err = ResolvePersistedQueries(Config{}, "github.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := DefaultPersistedQueries().Merge(PersistedQueries{Delete: "env-delete"}); got != want {
+		t.Fatalf("expected built-in queries for an uncached host, got %+v", got)
+	}
+}
+
+func TestResolvePersistedQueriesWithCorruptCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("GH_SAVED_ISSUES_QUERY_DELETE", "env-delete")
+
+	path, err := ResolveQueryCachePath()
+	if err != nil {
+		t.Fatalf("resolve cache path: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create cache dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("write cache: %v", err)
+	}
+
+	cfg := Config{PersistedQueries: PersistedQueries{Update: "config-update"}}
+	got, err := ResolvePersistedQueries(cfg, "github.com")
+	if err == nil {
+		t.Fatalf("expected the cache error to be reported")
+	}
+	if want := DefaultPersistedQueries().Merge(PersistedQueries{Update: "config-update", Delete: "env-delete"}); got != want {
+		t.Fatalf("expected built-in, config and env IDs, got %+v", got)
+	}
+}
+
+func TestQueryCacheIgnoresOtherBuiltins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.json")
+	var cache QueryCache
+	cached := NewCachedQueries(PersistedQueries{Create: "old"}, "test", nil)
+	cached.Builtin = "000000000000"
+	cache.Store("github.com", cached)
+	if err := SaveQueryCache(path, cache); err != nil {
+		t.Fatalf("save cache: %v", err)
+	}
+
+	loaded, err := LoadQueryCache(path)
+	if err != nil {
+		t.Fatalf("load cache: %v", err)
+	}
+	if _, ok := loaded.Lookup("github.com"); ok {
+		t.Fatalf("expected entry from other built-in IDs to be ignored")
+	}
+}
+
+func TestPersistedQueryNotFound(t *testing.T) {
+	var gotQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		gotQuery = req.Query
+		w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), endpoint: ts.URL, token: "token", cookie: "a=b"}
+	client.SetPersistedQueries(PersistedQueries{Create: "stale"})
+	_, err := client.CreateSavedSearch(context.Background(), SavedSearchInput{Name: "Mine", Query: "is:issue"})
+	if !errors.Is(err, ErrPersistedQueryNotFound) {
+		t.Fatalf("expected persisted query not found, got %v", err)
+	}
+	if gotQuery != "stale" {
+		t.Fatalf("expected the overridden ID, got %q", gotQuery)
+	}
+
+	if err := client.DeleteSavedSearch(context.Background(), "SSC_1"); !errors.Is(err, ErrPersistedQueryNotFound) || gotQuery != deletePersistedID {
+		t.Fatalf("expected the built-in delete ID, got %q %v", gotQuery, err)
+	}
+}
+
+func TestDiscoverQueries(t *testing.T) {
+	var pageCookie, bundleCookie string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/issues":
+			pageCookie = r.Header.Get("Cookie")
+			w.Write([]byte(`<html><script src="/assets/other.js"></script><script type="module" src="/assets/issues.js"></script></html>`))
+		case "/assets/other.js":
+			bundleCookie = r.Header.Get("Cookie")
+			w.Write([]byte(`const x={id:"44444444444444444444444444444444",name:"deleteShortcutMutation"};`))
+		case "/assets/issues.js":
+			w.Write([]byte(bundleJS))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client := &GraphQLClient{httpClient: ts.Client(), endpoint: ts.URL + "/_graphql", cookie: "user_session=abc"}
+	queries, content, err := client.DiscoverQueries(context.Background(), ts.URL+"/issues")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := PersistedQueries{
+		Create: "11111111111111111111111111111111",
+		Update: "22222222222222222222222222222222",
+		Delete: "44444444444444444444444444444444",
+		List:   "55555555555555555555555555555555",
+	}
+	if queries != want {
+		t.Fatalf("unexpected queries: %+v", queries)
+	}
+	if len(content) == 0 {
+		t.Fatalf("expected the searched content to be returned")
+	}
+	if pageCookie != "user_session=abc" || bundleCookie != "" {
+		t.Fatalf("expected the cookie only on the page, got %q and %q", pageCookie, bundleCookie)
+	}
+}
@@ -0,0 +1,202 @@
+package savedsearches
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mheap/gh-saved-issues/pkg/query"
+)
+
+// RenderedSearch is a configured search with its name and query resolved.
+type RenderedSearch struct {
+	Name    string
+	Section string // the section header the search sits under, if any
+	Header  bool   // the entry is itself a section header
+	Query   string
+	ID      string
+	Account string // the configured account, or "" for the default
+
+	WarnAbove int
+}
+
+// LintResult holds the diagnostics for one rendered search.
+type LintResult struct {
+	Name        string
+	Query       string
+	Diagnostics []query.Diagnostic
+}
+
+// ResolveVars returns the globals for rendering: built-in vars, looked up
+// through client when the config needs them, layered under GlobalVars.
+func (cfg Config) ResolveVars(ctx context.Context, client Client, overrides map[string]any) (map[string]any, error) {
+	builtins, err := ResolveBuiltins(ctx, client, realClock{}, cfg.UsesVars(viewerVarNames...))
+	if err != nil {
+		return nil, err
+	}
+	return MergeVars(builtins, cfg.GlobalVars(overrides)), nil
+}
+
+// RenderSearches renders every search in the config in order, expanding
+// matrix and for_each entries. Entries marked for removal are skipped.
+func RenderSearches(cfg Config, globals map[string]any) ([]RenderedSearch, error) {
+	return renderSearches(cfg, globals, func(string) bool { return true })
+}
+
+// RenderAccountSearches renders the searches of one account like
+// RenderSearches. "" and DefaultAccount are the default account. Other
+// accounts' entries are not rendered, so globals only need to suit this one.
+func RenderAccountSearches(cfg Config, account string, globals map[string]any) ([]RenderedSearch, error) {
+	if account == DefaultAccount {
+		account = ""
+	}
+	return renderSearches(cfg, globals, func(a string) bool { return a == account })
+}
+
+func renderSearches(cfg Config, globals map[string]any, keep func(account string) bool) ([]RenderedSearch, error) {
+	var out []RenderedSearch
+	section := ""
+	accounts := cfg.EntryAccounts()
+	for i, search := range cfg.Searches {
+		if isSectionHeader(search) && !search.Remove {
+			section = search.Section
+		}
+		if search.Remove || !keep(accounts[i]) {
+			continue
+		}
+
+		if search.IsExpanded() {
+			expansions, err := ExpandSearch(WithGlobalVars(search, globals))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", search.Name, err)
+			}
+			for _, exp := range expansions {
+				q, err := RenderQuery(exp.Definition, cfg.Templates)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", exp.Definition.Name, err)
+				}
+				out = append(out, RenderedSearch{Name: exp.Definition.Name, Section: section, Query: q, ID: exp.Definition.ID, Account: accounts[i], WarnAbove: search.WarnAbove})
+			}
+			continue
+		}
+
+		name, err := displayName(search)
+		if err != nil {
+			return nil, err
+		}
+		q, err := RenderQuery(WithGlobalVars(search, globals), cfg.Templates)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", search.Name, err)
+		}
+
+		header := isSectionHeader(search)
+		out = append(out, RenderedSearch{Name: name, Section: section, Header: header, Query: q, ID: search.ID, Account: accounts[i], WarnAbove: search.WarnAbove})
+	}
+	return out, nil
+}
+
+// LintConfig renders every search and lints its query. Section headers are
+// skipped since they have no query.
+func LintConfig(cfg Config, globals map[string]any) ([]LintResult, error) {
+	searches, err := RenderSearches(cfg, globals)
+	if err != nil {
+		return nil, err
+	}
+	return LintSearches(searches), nil
+}
+
+// LintSearches lints the query of every rendered search, skipping section
+// headers.
+func LintSearches(searches []RenderedSearch) []LintResult {
+	var results []LintResult
+	for _, s := range searches {
+		if s.Header {
+			continue
+		}
+		results = append(results, LintResult{Name: s.Name, Query: s.Query, Diagnostics: query.Lint(s.Query)})
+	}
+	return results
+}
+
+// ValidateConfig checks that a config can be synced: every account used is
+// defined, every entry renders, rendered names are unique within an account,
+// and no query has lint errors. It returns one
+// error per problem found.
+func ValidateConfig(cfg Config, globals map[string]any) []error {
+	accountGlobals := map[string]map[string]any{}
+	for _, account := range cfg.UsedAccounts() {
+		accountGlobals[account] = globals
+	}
+	return ValidateAccounts(cfg, accountGlobals)
+}
+
+// ValidateAccounts is ValidateConfig with each account's entries rendered
+// with that account's globals, keyed by account name ("" for the default).
+// Only the accounts in globals have their entries checked.
+func ValidateAccounts(cfg Config, globals map[string]map[string]any) []error {
+	var problems []error
+	names := make([]string, 0, len(cfg.Templates))
+	for name := range cfg.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := (&templateResolver{templates: cfg.Templates}).source(name); err != nil {
+			problems = append(problems, fmt.Errorf("template %s: %w", name, err))
+		}
+	}
+
+	if _, ok := cfg.Accounts[DefaultAccount]; ok {
+		problems = append(problems, fmt.Errorf("account name %q is reserved for searches without an account", DefaultAccount))
+	}
+	for _, account := range cfg.UsedAccounts() {
+		if _, err := cfg.LookupAccount(account); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	for _, account := range cfg.UsedAccounts() {
+		vars, ok := globals[account]
+		if !ok && account == "" {
+			vars, ok = globals[DefaultAccount]
+		}
+		if !ok {
+			continue
+		}
+		searches, err := RenderAccountSearches(cfg, account, vars)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+
+		seen := map[string]bool{}
+		for _, s := range searches {
+			if seen[s.Name] {
+				problems = append(problems, fmt.Errorf("%s: duplicate search name", s.Name))
+			}
+			seen[s.Name] = true
+			if s.Header {
+				continue
+			}
+			for _, d := range query.Lint(s.Query) {
+				if d.Severity == query.Error {
+					problems = append(problems, fmt.Errorf("%s: %s", s.Name, d))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// displayName is the name sent to GitHub: the search name, or the section
+// title for headers.
+func displayName(def SearchDefinition) (string, error) {
+	name := def.Name
+	if name == "" && def.Section != "" {
+		name = fmt.Sprintf("== %s ==", def.Section)
+	}
+	if name == "" {
+		return "", fmt.Errorf("search entry missing name")
+	}
+	return name, nil
+}
@@ -0,0 +1,290 @@
+package savedsearches
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mheap/gh-saved-issues/pkg/query"
+)
+
+func TestRenderSearches(t *testing.T) {
+	cfg := Config{
+		Vars: map[string]any{"org": "Kong"},
+		Searches: []SearchDefinition{
+			{Section: "Team", ID: "SSC_team"},
+			{Name: "Mine", Query: "is:issue org:{{ org }}", ID: "SSC_mine"},
+			{Name: "Work from {{ user }}", Template: "work", Matrix: map[string][]any{"user": {"alice", "bob"}}},
+			{Name: "Old", Query: "is:pr", Remove: true},
+		},
+		Templates: map[string]TemplateDefinition{
+			"work": {Query: "is:pr author:{{ user }}"},
+		},
+	}
+
+	searches, err := RenderSearches(cfg, cfg.GlobalVars(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []RenderedSearch{
+		{Name: "== Team ==", Section: "Team", Header: true, ID: "SSC_team"},
+		{Name: "Mine", Section: "Team", Query: "is:issue org:Kong", ID: "SSC_mine"},
+		{Name: "Work from alice", Section: "Team", Query: "author:alice is:pr"},
+		{Name: "Work from bob", Section: "Team", Query: "author:bob is:pr"},
+	}
+	if len(searches) != len(want) {
+		t.Fatalf("expected %d searches, got %+v", len(want), searches)
+	}
+	for i := range want {
+		if searches[i] != want[i] {
+			t.Fatalf("index %d: expected %+v, got %+v", i, want[i], searches[i])
+		}
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	cfg := Config{
+		Searches: []SearchDefinition{
+			{Section: "Team"},
+			{Name: "Typo", Query: "is:issue state:opne"},
+			{Name: "Fine", Query: "is:pr state:open"},
+		},
+	}
+
+	results, err := LintConfig(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected headers to be skipped, got %+v", results)
+	}
+	if len(results[0].Diagnostics) != 1 || !strings.Contains(results[0].Diagnostics[0].Message, "stat") {
+		t.Fatalf("expected typo diagnostic, got %+v", results[0].Diagnostics)
+	}
+	if len(results[1].Diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %+v", results[1].Diagnostics)
+	}
+}
+
+func TestSyncerRejectsInvalidQuery(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+searches:
+  - name: Broken
+    id: SSC_broken
+    query: "is:issue (state:open"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &stubClient{}
+	syncer := newTestSyncer(client, WithMode(ModeRecreate), WithStrictLint(true))
+	err := syncer.Sync(context.Background(), cfgPath)
+	if err == nil || !strings.Contains(err.Error(), "lint Broken") {
+		t.Fatalf("expected lint error, got %v", err)
+	}
+	if len(client.deleted) != 0 || len(client.created) != 0 {
+		t.Fatalf("expected no changes for invalid query, got d:%+v c:%+v", client.deleted, client.created)
+	}
+	if results := syncer.Results(); len(results) != 1 || results[0].Action != ActionRecreate || !strings.Contains(results[0].Error, "lint Broken") {
+		t.Fatalf("expected the failure in the results, got %+v", results)
+	}
+}
+
+func TestSyncerReportsLintErrorsWithoutStrict(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{{Name: "Broken", Query: "is:issue (state:open"}}}
+
+	client := &stubClient{}
+	var diags []query.Diagnostic
+	syncer := newTestSyncer(client, WithObserver(ObserverFunc(func(e Event) {
+		if e.Kind == EventActionPlanned {
+			diags = append(diags, e.Diagnostics...)
+		}
+	})))
+	if _, _, err := syncer.SyncConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("expected lint errors to be advisory, got %v", err)
+	}
+	if len(client.created) != 1 {
+		t.Fatalf("expected the search to be created, got %+v", client.created)
+	}
+	if !query.HasErrors(diags) {
+		t.Fatalf("expected the lint errors to be reported, got %+v", diags)
+	}
+}
+
+func TestFindSearch(t *testing.T) {
+	searches := []RenderedSearch{
+		{Name: "== Team ==", Header: true},
+		{Name: "Assigned to me", Query: "is:issue assignee:@me"},
+	}
+
+	found, err := FindSearch(searches, "assigned to ME")
+	if err != nil || found.Query != "is:issue assignee:@me" {
+		t.Fatalf("unexpected result: %+v %v", found, err)
+	}
+	if _, err := FindSearch(searches, "== Team =="); err == nil {
+		t.Fatalf("expected headers not to match")
+	}
+}
+
+func TestFindSearchFuzzy(t *testing.T) {
+	searches := []RenderedSearch{
+		{Name: "Terraform PRs", Query: "q1"},
+		{Name: "Terraform issues", Query: "q2"},
+		{Name: "PRs", Query: "q3"},
+	}
+
+	cases := []struct {
+		name string
+		want string
+		err  string
+	}{
+		{name: "prs", want: "q3"},
+		{name: "form iss", want: "q2"},
+		{name: "tfprs", want: "q1"},
+		{name: "terraform", err: "matches several searches: Terraform PRs, Terraform issues"},
+		{name: "zzz", err: "no search named"},
+	}
+
+	for _, tc := range cases {
+		found, err := FindSearch(searches, tc.name)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("%s: expected error %q, got %v", tc.name, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || found.Query != tc.want {
+			t.Fatalf("%s: expected %s, got %+v %v", tc.name, tc.want, found, err)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cfg := Config{
+		Searches: []SearchDefinition{
+			{Name: "Mine", Query: "is:pr author:@me"},
+			{Name: "Mine", Query: "is:issue author:@me"},
+			{Name: "Typo", Query: "is:issue state:opne"},
+		},
+		Templates: map[string]TemplateDefinition{
+			"loop": {Extends: "loop", Query: "is:pr"},
+		},
+	}
+
+	problems := ValidateConfig(cfg, nil)
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+	if len(got) != 3 ||
+		!strings.Contains(got[0], "template loop: template extends cycle") ||
+		got[1] != "Mine: duplicate search name" ||
+		!strings.HasPrefix(got[2], `Typo: error: column 10: invalid value "opne" for state`) {
+		t.Fatalf("unexpected problems: %q", got)
+	}
+
+	if problems := ValidateConfig(Config{Searches: cfg.Searches[:1]}, nil); len(problems) != 0 {
+		t.Fatalf("expected valid config, got %v", problems)
+	}
+}
+
+func TestValidateConfigAccounts(t *testing.T) {
+	cfg := Config{
+		Accounts: map[string]AccountDefinition{"work": {}},
+		Searches: []SearchDefinition{
+			{Name: "Mine", Query: "is:pr author:@me"},
+			{Name: "Mine", Query: "is:pr author:@me", Account: "work"},
+			{Name: "Side", Query: "is:issue author:@me", Account: "play"},
+		},
+	}
+
+	problems := ValidateConfig(cfg, nil)
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), `unknown account "play"`) {
+		t.Fatalf("expected only the unknown account, got %v", problems)
+	}
+}
+
+func TestRenderSearchesVarPrecedence(t *testing.T) {
+	t.Setenv("SAVED_SEARCH_VAR_STATE", "env")
+	cfg := Config{
+		Vars: map[string]any{"state": "config", "org": "config"},
+		Searches: []SearchDefinition{
+			{Name: "Global", Query: "org:{{ org }} state:{{ state }}"},
+			{Name: "Entry", Query: "org:{{ org }} state:{{ state }}", Vars: map[string]any{"org": "entry"}},
+		},
+	}
+
+	searches, err := RenderSearches(cfg, cfg.GlobalVars(map[string]any{"org": "cli"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if searches[0].Query != "org:cli state:env" {
+		t.Fatalf("expected --var to override config and environment vars, got %q", searches[0].Query)
+	}
+	if searches[1].Query != "org:entry state:env" {
+		t.Fatalf("expected entry vars to override --var, got %q", searches[1].Query)
+	}
+}
+
+func TestSyncerRecordsEntriesThatFailBeforeAnAction(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{{Name: "Broken", Query: "is:pr {{ .missing.field }"}}}
+
+	syncer := newTestSyncer(&stubClient{})
+	if _, _, err := syncer.SyncConfig(context.Background(), cfg); err == nil {
+		t.Fatalf("expected a render error")
+	}
+	if results := syncer.Results(); len(results) != 1 || results[0].Name != "Broken" || results[0].Error == "" {
+		t.Fatalf("expected the failure in the results, got %+v", results)
+	}
+}
+
+func TestRenderAccountSearches(t *testing.T) {
+	cfg := Config{
+		Accounts: map[string]AccountDefinition{"work": {}},
+		Searches: []SearchDefinition{
+			{Name: "Mine", Query: "author:{{ login }}"},
+			{Section: "Work", Account: "work"},
+			{Name: "Reviews", Query: "review-requested:{{ login }}"},
+		},
+	}
+
+	work, err := RenderAccountSearches(cfg, "work", map[string]any{"login": "alice-corp"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(work) != 2 || work[1].Name != "Reviews" || work[1].Query != "review-requested:alice-corp" || work[1].Section != "Work" {
+		t.Fatalf("unexpected work searches: %+v", work)
+	}
+
+	personal, err := RenderAccountSearches(cfg, DefaultAccount, map[string]any{"login": "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(personal) != 1 || personal[0].Query != "author:alice" {
+		t.Fatalf("unexpected default account searches: %+v", personal)
+	}
+}
+
+func TestValidateAccountsUsesEachAccountsGlobals(t *testing.T) {
+	cfg := Config{
+		Accounts: map[string]AccountDefinition{"work": {}},
+		Searches: []SearchDefinition{
+			{Name: "Mine", Query: "is:issue state:{{ state }}"},
+			{Name: "Work", Query: "is:issue state:{{ state }}", Account: "work"},
+		},
+	}
+
+	problems := ValidateAccounts(cfg, map[string]map[string]any{
+		DefaultAccount: {"state": "open"},
+		"work":         {"state": "opne"},
+	})
+	if len(problems) != 1 || !strings.HasPrefix(problems[0].Error(), "Work: ") {
+		t.Fatalf("expected only the work search to fail, got %v", problems)
+	}
+}
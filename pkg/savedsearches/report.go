@@ -0,0 +1,128 @@
+package savedsearches
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReportFormats lists the formats supported by WriteReport.
+var ReportFormats = []string{"text", "json", "yaml"}
+
+// SyncTotals counts sync results by outcome.
+type SyncTotals struct {
+	Created    int   `json:"created" yaml:"created"`
+	Updated    int   `json:"updated" yaml:"updated"`
+	Recreated  int   `json:"recreated" yaml:"recreated"`
+	Deleted    int   `json:"deleted" yaml:"deleted"`
+	Unchanged  int   `json:"unchanged" yaml:"unchanged"`
+	Failed     int   `json:"failed" yaml:"failed"`
+	DurationMS int64 `json:"duration_ms" yaml:"duration_ms"`
+}
+
+// SyncReport is the outcome of a sync: one result per entry plus totals.
+type SyncReport struct {
+	Entries []EntryResult `json:"entries" yaml:"entries"`
+	Totals  SyncTotals    `json:"totals" yaml:"totals"`
+}
+
+// NewSyncReport totals up results. Failed entries are only counted as
+// failed, whatever they were attempting.
+func NewSyncReport(results []EntryResult) SyncReport {
+	report := SyncReport{Entries: results}
+	if report.Entries == nil {
+		report.Entries = []EntryResult{}
+	}
+	for _, r := range results {
+		report.Totals.DurationMS += r.DurationMS
+		if r.Error != "" {
+			report.Totals.Failed++
+			continue
+		}
+		switch r.Action {
+		case ActionCreate:
+			report.Totals.Created++
+		case ActionUpdate:
+			report.Totals.Updated++
+		case ActionRecreate:
+			report.Totals.Recreated++
+		case ActionDelete:
+			report.Totals.Deleted++
+		case ActionUnchanged:
+			report.Totals.Unchanged++
+		}
+	}
+	return report
+}
+
+// actionColors are the ANSI colors used for each action in text reports.
+var actionColors = map[Action]string{
+	ActionCreate:    "\033[32m",
+	ActionUpdate:    "\033[33m",
+	ActionRecreate:  "\033[36m",
+	ActionDelete:    "\033[31m",
+	ActionUnchanged: "\033[2m",
+}
+
+// WriteReport writes the report as json, yaml, or a text summary that is
+// colored when color is set.
+func WriteReport(w io.Writer, format string, report SyncReport, color bool) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "text":
+		return writeReportText(w, report, color)
+	default:
+		return fmt.Errorf("unknown output format %q (want one of %s)", format, strings.Join(ReportFormats, ", "))
+	}
+}
+
+func writeReportText(w io.Writer, report SyncReport, color bool) error {
+	paint := func(code, s string) string {
+		if !color || code == "" {
+			return s
+		}
+		return code + s + "\033[0m"
+	}
+
+	var b strings.Builder
+	for _, r := range report.Entries {
+		if r.Action == ActionUnchanged {
+			continue
+		}
+		name := r.Name
+		if r.Account != "" {
+			name += " (" + r.Account + ")"
+		}
+		if r.Error != "" {
+			fmt.Fprintf(&b, "%s %s: %s\n", paint("\033[31m", "failed   "), name, r.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s\n", paint(actionColors[r.Action], fmt.Sprintf("%-9s", r.Action)), name)
+		if r.Before != "" && r.After != "" && r.Before != r.After {
+			fmt.Fprintf(&b, "  - %s\n  + %s\n", r.Before, r.After)
+		}
+	}
+
+	t := report.Totals
+	summary := fmt.Sprintf("%d created, %d updated, %d recreated, %d deleted, %d unchanged", t.Created, t.Updated, t.Recreated, t.Deleted, t.Unchanged)
+	if t.Failed > 0 {
+		summary += ", " + paint("\033[31m", fmt.Sprintf("%d failed", t.Failed))
+	}
+	fmt.Fprintf(&b, "%s (%.1fs)\n", summary, float64(t.DurationMS)/1000)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
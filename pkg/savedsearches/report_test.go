@@ -0,0 +1,91 @@
+package savedsearches
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func sampleResults() []EntryResult {
+	return []EntryResult{
+		{Name: "New", ID: "SSC_new", Action: ActionCreate, After: "is:pr", DurationMS: 1200},
+		{Name: "Changed", ID: "SSC_changed", Action: ActionUpdate, Before: "is:pr state:open", After: "is:pr state:closed", DurationMS: 1100},
+		{Name: "Same", ID: "SSC_same", Action: ActionUnchanged, Before: "is:issue", After: "is:issue"},
+		{Name: "Old", ID: "SSC_old", Action: ActionDelete, Before: "is:issue", DurationMS: 200},
+		{Name: "Broken", Action: ActionCreate, After: "stat:open", Error: "lint Broken: query has errors"},
+	}
+}
+
+func TestNewSyncReportTotals(t *testing.T) {
+	report := NewSyncReport(sampleResults())
+	want := SyncTotals{Created: 1, Updated: 1, Deleted: 1, Unchanged: 1, Failed: 1, DurationMS: 2500}
+	if report.Totals != want {
+		t.Fatalf("unexpected totals: %+v", report.Totals)
+	}
+
+	if empty := NewSyncReport(nil); empty.Entries == nil {
+		t.Fatalf("expected an empty entries list so JSON has [] rather than null")
+	}
+}
+
+func TestWriteReportJSONAndYAML(t *testing.T) {
+	report := NewSyncReport(sampleResults())
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, "json", report, false); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	var decoded SyncReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(decoded.Entries) != 5 || decoded.Entries[1].Before != "is:pr state:open" || decoded.Totals.Failed != 1 {
+		t.Fatalf("unexpected json report: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteReport(&buf, "yaml", report, false); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+	decoded = SyncReport{}
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode yaml: %v", err)
+	}
+	if decoded.Entries[4].Error == "" || decoded.Totals.Created != 1 {
+		t.Fatalf("unexpected yaml report: %s", buf.String())
+	}
+
+	if err := WriteReport(&buf, "xml", report, false); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestWriteReportText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, "text", NewSyncReport(sampleResults()), false); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+
+	want := `create    New
+update    Changed
+  - is:pr state:open
+  + is:pr state:closed
+delete    Old
+failed    Broken: lint Broken: query has errors
+1 created, 1 updated, 0 recreated, 1 deleted, 1 unchanged, 1 failed (2.5s)
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected text report:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteReport(&buf, "text", NewSyncReport(sampleResults()), true); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	if !strings.Contains(buf.String(), "\033[32mcreate   \033[0m New") {
+		t.Fatalf("expected colored actions, got %q", buf.String())
+	}
+}
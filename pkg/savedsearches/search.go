@@ -0,0 +1,157 @@
+package savedsearches
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SearchItem is an issue or pull request returned by a search.
+type SearchItem struct {
+	Number      int       `json:"number"`
+	Repo        string    `json:"repository"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	URL         string    `json:"url"`
+	State       string    `json:"state"`
+	PullRequest bool      `json:"isPullRequest"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// SearchResult is a page of search results and the total match count.
+type SearchResult struct {
+	Total int          `json:"total"`
+	Items []SearchItem `json:"items"`
+}
+
+// Searcher runs issue and pull request searches.
+type Searcher interface {
+	Search(ctx context.Context, query string, limit int) (SearchResult, error)
+}
+
+const searchQuery = `query($q: String!, $first: Int!) {
+  search(query: $q, type: ISSUE, first: $first) {
+    issueCount
+    nodes {
+      __typename
+      ... on Issue { number title url state updatedAt author { login } repository { nameWithOwner } }
+      ... on PullRequest { number title url state updatedAt author { login } repository { nameWithOwner } }
+    }
+  }
+}`
+
+// maxSearchLimit is the largest page the search API accepts.
+const maxSearchLimit = 100
+
+// Search runs a query against the search API and returns up to limit items.
+// The limit is clamped to 1..100, the range the API accepts; callers that only
+// need the total count can pass 0.
+func (c *GraphQLClient) Search(ctx context.Context, query string, limit int) (SearchResult, error) {
+	limit = min(max(limit, 1), maxSearchLimit)
+	data, err := c.apiGraphQL(ctx, searchQuery, map[string]any{"q": query, "first": limit})
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("search: %w", err)
+	}
+
+	// Round-trip through JSON to decode the generic response into typed nodes.
+	raw, err := json.Marshal(data["search"])
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("search: %w", err)
+	}
+
+	var parsed struct {
+		IssueCount int `json:"issueCount"`
+		Nodes      []struct {
+			Typename   string                 `json:"__typename"`
+			Number     int                    `json:"number"`
+			Title      string                 `json:"title"`
+			URL        string                 `json:"url"`
+			State      string                 `json:"state"`
+			UpdatedAt  time.Time              `json:"updatedAt"`
+			Author     struct{ Login string } `json:"author"`
+			Repository struct {
+				NameWithOwner string `json:"nameWithOwner"`
+			} `json:"repository"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return SearchResult{}, fmt.Errorf("parse search response: %w", err)
+	}
+
+	result := SearchResult{Total: parsed.IssueCount, Items: []SearchItem{}}
+	for _, n := range parsed.Nodes {
+		if n.Number == 0 {
+			continue
+		}
+		result.Items = append(result.Items, SearchItem{
+			Number:      n.Number,
+			Repo:        n.Repository.NameWithOwner,
+			Title:       n.Title,
+			Author:      n.Author.Login,
+			URL:         n.URL,
+			State:       n.State,
+			PullRequest: n.Typename == "PullRequest",
+			UpdatedAt:   n.UpdatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// FindSearch resolves a name to a rendered search. An exact, case-insensitive
+// match wins; otherwise the name is matched as a substring and then as a
+// subsequence of the search names (so "tfprs" finds "Terraform PRs"). Section
+// headers are not matched, and ambiguous names are reported with candidates.
+func FindSearch(searches []RenderedSearch, name string) (RenderedSearch, error) {
+	want := strings.ToLower(name)
+	matchers := []func(string) bool{
+		func(n string) bool { return n == want },
+		func(n string) bool { return strings.Contains(n, want) },
+		func(n string) bool { return isSubsequence(want, n) },
+	}
+
+	for _, match := range matchers {
+		var found []RenderedSearch
+		for _, s := range searches {
+			if !s.Header && match(strings.ToLower(s.Name)) {
+				found = append(found, s)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			names := make([]string, len(found))
+			for i, s := range found {
+				names[i] = strings.TrimSpace(s.Name)
+			}
+			return RenderedSearch{}, fmt.Errorf("%q matches several searches: %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	return RenderedSearch{}, fmt.Errorf("no search named %q", name)
+}
+
+// isSubsequence reports whether the runes of sub appear in s in order,
+// ignoring spaces in sub.
+func isSubsequence(sub, s string) bool {
+	rest := []rune(s)
+	for _, r := range sub {
+		if r == ' ' {
+			continue
+		}
+		i := 0
+		for i < len(rest) && rest[i] != r {
+			i++
+		}
+		if i == len(rest) {
+			return false
+		}
+		rest = rest[i+1:]
+	}
+	return true
+}
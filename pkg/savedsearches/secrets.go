@@ -0,0 +1,198 @@
+package savedsearches
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// SecretStore keeps named secrets such as session cookies.
+// EncryptedStore and KeyringStore implement it.
+type SecretStore interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// SecretStoreKinds lists the stores OpenSecretStore can open.
+var SecretStoreKinds = []string{"keyring", "file"}
+
+// secretStoreEnv picks the store when none is asked for explicitly.
+const secretStoreEnv = "GH_SAVED_ISSUES_STORE"
+
+// keyringService is the service name secrets are filed under in the OS
+// keyring.
+const keyringService = "gh-saved-issues"
+
+// OpenSecretStore opens a store by kind: keyring for the OS keyring or file
+// for the DefaultEncryptedStore. An empty kind uses $GH_SAVED_ISSUES_STORE,
+// or else the keyring when one is available and the file otherwise.
+func OpenSecretStore(kind string) (SecretStore, error) {
+	if kind == "" {
+		kind = os.Getenv(secretStoreEnv)
+	}
+	if kind == "" {
+		kind = "file"
+		if KeyringAvailable() {
+			kind = "keyring"
+		}
+	}
+
+	switch kind {
+	case "keyring":
+		if !KeyringAvailable() {
+			return nil, errors.New("no OS keyring available; use the encrypted file store instead")
+		}
+		return KeyringStore{Service: keyringService}, nil
+	case "file":
+		return DefaultEncryptedStore()
+	default:
+		return nil, fmt.Errorf("unknown secret store %q (want one of %s)", kind, strings.Join(SecretStoreKinds, ", "))
+	}
+}
+
+// SessionSecretName is the name a session cookie is stored under: the host,
+// followed by the account for configured accounts.
+func SessionSecretName(host, account string) string {
+	name := "cookie:" + NormalizeHost(host)
+	if account != "" && account != DefaultAccount {
+		name += ":" + account
+	}
+	return name
+}
+
+// storedCookie returns the session cookie saved by login, or "" when there
+// is none or no store can be opened. Unless $GH_SAVED_ISSUES_STORE picks a
+// store, both are tried, since login --store may have used either.
+func storedCookie(host, account string) (string, error) {
+	kinds := SecretStoreKinds
+	if kind := os.Getenv(secretStoreEnv); kind != "" {
+		kinds = []string{kind}
+	}
+
+	name := SessionSecretName(host, account)
+	for _, kind := range kinds {
+		store, err := OpenSecretStore(kind)
+		if err != nil {
+			continue
+		}
+		cookie, err := store.Get(name)
+		if errors.Is(err, ErrSecretNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("read stored cookie: %w", err)
+		}
+		return cookie, nil
+	}
+	return "", nil
+}
+
+// KeyringStore keeps secrets in the OS keyring: the login keychain on macOS
+// and the Secret Service (GNOME Keyring, KWallet) on Linux. It drives the
+// security and secret-tool commands.
+type KeyringStore struct {
+	Service string
+}
+
+// KeyringAvailable reports whether this machine has a keyring KeyringStore
+// can use. Linux needs secret-tool and a D-Bus session, which headless
+// machines usually lack.
+func KeyringAvailable() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "linux":
+		_, err := exec.LookPath("secret-tool")
+		return err == nil && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != ""
+	}
+	return false
+}
+
+// Get returns the named secret.
+func (k KeyringStore) Get(name string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", k.Service, "-a", name, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", k.Service, "account", name)
+	}
+
+	out, err := runKeyring(cmd, "")
+	// Both tools exit non-zero with no output when there is no such item.
+	if out == "" {
+		if err == nil || errors.As(err, new(*exec.ExitError)) {
+			return "", fmt.Errorf("%s: %w", name, ErrSecretNotFound)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// Set stores a secret, replacing any with the same name. The value is passed
+// on stdin so it doesn't show up in the process list: to secret-tool
+// directly, and to macOS's security command as an interactive-mode command.
+func (k KeyringStore) Set(name, value string) error {
+	if runtime.GOOS == "darwin" {
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New("secret contains a line break")
+		}
+		command := strings.Join([]string{"add-generic-password", "-U", "-s", securityQuote(k.Service), "-a", securityQuote(name), "-w", securityQuote(value)}, " ")
+		if _, err := runKeyring(exec.Command("security", "-i"), command+"\n"); err != nil {
+			return err
+		}
+		// Interactive mode exits zero even when a command fails, so check
+		// that the secret arrived.
+		if got, err := k.Get(name); err != nil || got != value {
+			return fmt.Errorf("security: storing %s in the keychain failed", name)
+		}
+		return nil
+	}
+	_, err := runKeyring(exec.Command("secret-tool", "store", "--label", k.Service+" "+name, "service", k.Service, "account", name), value)
+	return err
+}
+
+// Delete removes a secret. Deleting a missing secret is not an error.
+func (k KeyringStore) Delete(name string) error {
+	if _, err := k.Get(name); errors.Is(err, ErrSecretNotFound) {
+		return nil
+	}
+	if runtime.GOOS == "darwin" {
+		_, err := runKeyring(exec.Command("security", "delete-generic-password", "-s", k.Service, "-a", name), "")
+		return err
+	}
+	_, err := runKeyring(exec.Command("secret-tool", "clear", "service", k.Service, "account", name), "")
+	return err
+}
+
+// securityQuote quotes an argument for security's interactive mode, which
+// splits commands on spaces and understands double quotes and backslash
+// escapes.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// runKeyring runs a keyring command with stdin, returning its trimmed
+// output. Errors include the command's stderr.
+func runKeyring(cmd *exec.Cmd, stdin string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	out := strings.TrimSpace(stdout.String())
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return out, fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return out, nil
+}
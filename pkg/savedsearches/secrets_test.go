@@ -0,0 +1,97 @@
+package savedsearches
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSessionSecretName(t *testing.T) {
+	if got := SessionSecretName("GitHub.com", ""); got != "cookie:github.com" {
+		t.Fatalf("unexpected default name %s", got)
+	}
+	if got := SessionSecretName("ghe.example.com", "work"); got != "cookie:ghe.example.com:work" {
+		t.Fatalf("unexpected account name %s", got)
+	}
+	if got := SessionSecretName("github.com", DefaultAccount); got != "cookie:github.com" {
+		t.Fatalf("expected the default account to use the host name, got %s", got)
+	}
+}
+
+func TestOpenSecretStoreUnknownKind(t *testing.T) {
+	if _, err := OpenSecretStore("vault"); err == nil || !strings.Contains(err.Error(), `unknown secret store "vault"`) {
+		t.Fatalf("expected unknown store error, got %v", err)
+	}
+}
+
+func TestClientReadsStoredCookie(t *testing.T) {
+	fastKDF(t)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GH_SAVED_ISSUES_STORE", "file")
+	t.Setenv("GH_SAVED_ISSUES_PASSPHRASE", "correct horse")
+	t.Setenv("GH_TOKEN", "token")
+	t.Setenv("GH_COOKIE", "")
+	t.Setenv("GITHUB_COOKIE", "")
+	t.Setenv("GH_COOKIE_FILE", "")
+
+	store, err := OpenSecretStore("")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	if err := store.Set(SessionSecretName("github.com", ""), "user_session=stored"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.Set(SessionSecretName("github.com", "work"), "user_session=work"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	client, err := NewGraphQLClientForHost(context.Background(), "github.com")
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if client.cookie != "user_session=stored" {
+		t.Fatalf("expected the stored cookie, got %q", client.cookie)
+	}
+
+	client, err = NewAccountClient(context.Background(), "work", AccountDefinition{Token: "env:GH_TOKEN"}, "github.com")
+	if err != nil {
+		t.Fatalf("new account client: %v", err)
+	}
+	if client.cookie != "user_session=work" {
+		t.Fatalf("expected the account's stored cookie, got %q", client.cookie)
+	}
+
+	t.Setenv("GH_COOKIE", "user_session=env")
+	if client, _ := NewGraphQLClientForHost(context.Background(), "github.com"); client.cookie != "user_session=env" {
+		t.Fatalf("expected GH_COOKIE to win, got %q", client.cookie)
+	}
+}
+
+func TestStoredCookieFallsBackToFileStore(t *testing.T) {
+	fastKDF(t)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GH_SAVED_ISSUES_STORE", "")
+	t.Setenv("GH_SAVED_ISSUES_PASSPHRASE", "correct horse")
+
+	// login --store file, with no store selected when the cookie is read.
+	store, err := OpenSecretStore("file")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	name := "test-" + t.Name()
+	if err := store.Set(SessionSecretName(name, ""), "user_session=file"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	if cookie, err := storedCookie(name, ""); err != nil || cookie != "user_session=file" {
+		t.Fatalf("expected the file store's cookie, got %q %v", cookie, err)
+	}
+}
+
+func TestSecurityQuote(t *testing.T) {
+	if got := securityQuote(`a=b; c="d\e"`); got != `"a=b; c=\"d\\e\""` {
+		t.Fatalf("unexpected quoting: %s", got)
+	}
+}
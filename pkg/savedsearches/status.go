@@ -0,0 +1,83 @@
+package savedsearches
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mheap/gh-saved-issues/pkg/query"
+)
+
+// StatusRow is the open result count for one configured search.
+type StatusRow struct {
+	Name      string
+	Section   string
+	Account   string
+	Query     string
+	Count     int
+	WarnAbove int
+	Err       error
+}
+
+// Warn reports whether the count crossed the search's warn_above threshold.
+func (r StatusRow) Warn() bool {
+	return r.Err == nil && r.WarnAbove > 0 && r.Count > r.WarnAbove
+}
+
+// Status counts the open results for every search, skipping section headers.
+// A failed search is reported on its row rather than stopping the others.
+func Status(ctx context.Context, searcher Searcher, searches []RenderedSearch) []StatusRow {
+	var rows []StatusRow
+	for _, s := range searches {
+		if s.Header {
+			continue
+		}
+
+		q := OpenQuery(s.Query)
+		row := StatusRow{Name: s.Name, Section: s.Section, Account: s.Account, Query: q, WarnAbove: s.WarnAbove}
+		result, err := searcher.Search(ctx, q, 0)
+		if err != nil {
+			row.Err = err
+		} else {
+			row.Count = result.Total
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// OpenQuery restricts a query to open items unless it already filters by
+// state, e.g. with state:closed or is:merged. A query with a top-level OR is
+// parenthesized first so is:open applies to every alternative.
+func OpenQuery(q string) string {
+	tokens, err := query.Tokenize(q)
+	if err != nil {
+		return q
+	}
+	depth, topLevelOr := 0, false
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case query.LParen:
+			depth++
+		case query.RParen:
+			depth--
+		case query.Or:
+			topLevelOr = topLevelOr || depth == 0
+		}
+		if tok.Kind != query.Qualifier {
+			continue
+		}
+		if tok.Key == "state" {
+			return q
+		}
+		if tok.Key == "is" {
+			switch strings.ToLower(tok.Value) {
+			case "open", "closed", "merged", "unmerged":
+				return q
+			}
+		}
+	}
+	if topLevelOr {
+		q = "(" + q + ")"
+	}
+	return query.Format(q + " is:open")
+}
@@ -0,0 +1,63 @@
+package savedsearches
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type stubSearcher struct {
+	counts  map[string]int
+	queries []string
+}
+
+func (s *stubSearcher) Search(ctx context.Context, q string, limit int) (SearchResult, error) {
+	s.queries = append(s.queries, q)
+	count, ok := s.counts[q]
+	if !ok {
+		return SearchResult{}, errors.New("boom")
+	}
+	return SearchResult{Total: count}, nil
+}
+
+func TestStatus(t *testing.T) {
+	searcher := &stubSearcher{counts: map[string]int{
+		"is:issue is:open": 12,
+		"is:pr state:open": 3,
+	}}
+	searches := []RenderedSearch{
+		{Name: "== Team ==", Section: "Team", Header: true},
+		{Name: "Issues", Section: "Team", Query: "is:issue", WarnAbove: 10},
+		{Name: "PRs", Section: "Team", Query: "is:pr state:open", WarnAbove: 10},
+		{Name: "Broken", Query: "is:pr is:closed"},
+	}
+
+	rows := Status(context.Background(), searcher, searches)
+	if len(rows) != 3 {
+		t.Fatalf("expected headers skipped, got %+v", rows)
+	}
+	if rows[0].Count != 12 || !rows[0].Warn() || rows[0].Section != "Team" {
+		t.Fatalf("unexpected row: %+v", rows[0])
+	}
+	if rows[1].Count != 3 || rows[1].Warn() {
+		t.Fatalf("unexpected row: %+v", rows[1])
+	}
+	if rows[2].Err == nil || rows[2].Warn() {
+		t.Fatalf("expected error row, got %+v", rows[2])
+	}
+}
+
+func TestOpenQuery(t *testing.T) {
+	cases := map[string]string{
+		"is:issue assignee:@me":      "assignee:@me is:issue is:open",
+		"is:pr state:closed":         "is:pr state:closed",
+		"is:pr is:merged":            "is:pr is:merged",
+		"author:a OR author:b":       "is:open (author:a OR author:b)",
+		"is:pr (label:a OR label:b)": "is:pr is:open (label:a OR label:b)",
+	}
+	for in, want := range cases {
+		if got := OpenQuery(in); got != want {
+			t.Fatalf("%s: expected %q, got %q", in, want, got)
+		}
+	}
+}
@@ -2,20 +2,182 @@ package savedsearches
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 	"time"
+
+	"github.com/mheap/gh-saved-issues/pkg/query"
+)
+
+// Action describes what the syncer did, or would do, with a search.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionRecreate  Action = "recreate"
+	ActionDelete    Action = "delete"
+	ActionUnchanged Action = "unchanged"
+)
+
+// EntryResult records the action taken for one saved search. Before is the
+// query on GitHub, when known, and After the query it was set to.
+type EntryResult struct {
+	Name       string `json:"name" yaml:"name"`
+	Account    string `json:"account,omitempty" yaml:"account,omitempty"`
+	ID         string `json:"id,omitempty" yaml:"id,omitempty"`
+	Action     Action `json:"action" yaml:"action"`
+	Before     string `json:"before,omitempty" yaml:"before,omitempty"`
+	After      string `json:"after,omitempty" yaml:"after,omitempty"`
+	DurationMS int64  `json:"duration_ms" yaml:"duration_ms"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Mode selects how the syncer treats searches that already exist.
+type Mode int
+
+const (
+	// ModeSync creates missing searches and updates existing ones.
+	ModeSync Mode = iota
+	// ModeRecreate deletes and recreates every configured search.
+	ModeRecreate
+	// ModeReset deletes every configured search without recreating it.
+	ModeReset
 )
 
+// defaultRateLimit is how many writes per second the syncer sends unless
+// told otherwise, to stay clear of GitHub's secondary rate limits.
+const defaultRateLimit = 1.0
+
 // Syncer applies configuration to GitHub.
 type Syncer struct {
-	client   Client
-	recreate bool
-	reset    bool
+	client  Client
+	account string
+	mode    Mode
+	dryRun  bool
+	strict  bool
+	vars    map[string]any
+	live    map[string]SavedSearch
+	results []EntryResult
+
+	// viewerVars caches the built-in vars from the viewer lookup; the time
+	// builtins are resolved on every sync.
+	viewerVars map[string]any
+
+	clock   Clock
+	limiter Limiter
+	rate    float64
+	delay   time.Duration
+
+	observers []Observer
+	logger    *slog.Logger
+}
+
+// Option configures a Syncer.
+type Option func(*Syncer)
+
+// WithMode sets whether the syncer syncs, recreates or resets searches. The
+// default is ModeSync.
+func WithMode(mode Mode) Option {
+	return func(s *Syncer) { s.mode = mode }
+}
+
+// WithAccount makes the syncer handle only the searches belonging to the
+// named account, which the client must be authenticated as. Without it, the
+// syncer handles the searches that have no account.
+func WithAccount(name string) Option {
+	return func(s *Syncer) {
+		if name == DefaultAccount {
+			name = ""
+		}
+		s.account = name
+	}
+}
+
+// WithDryRun makes the syncer work out what it would do without calling
+// the API or writing the config. The results are available from Results.
+func WithDryRun(dryRun bool) Option {
+	return func(s *Syncer) { s.dryRun = dryRun }
+}
+
+// WithStrictLint makes lint errors in a rendered query fail its entry
+// instead of only being reported. Off by default: the qualifier table can
+// lag behind GitHub, and a query that works shouldn't stop a sync.
+func WithStrictLint(strict bool) Option {
+	return func(s *Syncer) { s.strict = strict }
+}
+
+// WithClock replaces the clock used for timing and throttling.
+func WithClock(clock Clock) Option {
+	return func(s *Syncer) { s.clock = clock }
+}
+
+// WithRateLimit caps how many create, update and delete calls are sent per
+// second. Zero or less disables the cap. The default is one per second.
+func WithRateLimit(perSecond float64) Option {
+	return func(s *Syncer) { s.rate = perSecond }
+}
+
+// WithDelay adds a fixed pause between create, update and delete calls, on
+// top of the rate limit.
+func WithDelay(delay time.Duration) Option {
+	return func(s *Syncer) { s.delay = delay }
+}
+
+// WithLimiter paces writes with a custom limiter instead of WithRateLimit
+// and WithDelay.
+func WithLimiter(limiter Limiter) Option {
+	return func(s *Syncer) { s.limiter = limiter }
+}
+
+// WithLogger logs sync events to logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Syncer) { s.logger = logger }
+}
+
+// WithObserver registers an observer for sync events. The syncer prints
+// nothing itself; add ProgressObserver(os.Stdout) for the CLI's output.
+func WithObserver(o Observer) Option {
+	return func(s *Syncer) { s.observers = append(s.observers, o) }
+}
+
+// WithVars sets var overrides, which take precedence over the config's
+// top-level vars and environment vars.
+func WithVars(vars map[string]any) Option {
+	return func(s *Syncer) { s.vars = vars }
 }
 
 // NewSyncer constructs a Syncer.
-func NewSyncer(client Client, recreate, reset bool) *Syncer {
-	return &Syncer{client: client, recreate: recreate, reset: reset}
+func NewSyncer(client Client, opts ...Option) *Syncer {
+	s := &Syncer{client: client, clock: realClock{}, rate: defaultRateLimit}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.limiter == nil {
+		// Built after the options so the limiters share the chosen clock.
+		s.limiter = limiters{NewRateLimiter(s.clock, s.rate), NewDelayLimiter(s.clock, s.delay)}
+	}
+	return s
+}
+
+// Plan works out what Sync would do without changing anything on GitHub or
+// in the config file.
+func (s *Syncer) Plan(ctx context.Context, configPath string) ([]EntryResult, error) {
+	dryRun := s.dryRun
+	s.dryRun = true
+	defer func() { s.dryRun = dryRun }()
+
+	if err := s.Sync(ctx, configPath); err != nil {
+		return s.results, err
+	}
+	return s.results, nil
+}
+
+// Results returns the actions taken by the last Sync or SyncConfig.
+func (s *Syncer) Results() []EntryResult {
+	return s.results
 }
 
 // Sync reads config, reconciles with GitHub, and writes any updates.
@@ -25,25 +187,92 @@ func (s *Syncer) Sync(ctx context.Context, configPath string) error {
 		return err
 	}
 
+	cfg, updated, err := s.SyncConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if updated && !s.dryRun {
+		if err := SaveConfig(configPath, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncConfig reconciles an already-loaded config with GitHub. It returns
+// the config with new and removed IDs applied, and whether any changed, so
+// the caller can store it; cfg itself is not modified.
+func (s *Syncer) SyncConfig(ctx context.Context, cfg Config) (Config, bool, error) {
+	s.results = nil
+	cfg.Searches = cloneSearches(cfg.Searches)
+	if _, err := cfg.LookupAccount(s.account); err != nil {
+		return cfg, false, err
+	}
+	accounts := cfg.EntryAccounts()
+
+	needViewer := cfg.UsesVars(viewerVarNames...)
+	if s.viewerVars == nil || (needViewer && s.viewerVars["login"] == nil) {
+		viewerVars, err := viewerBuiltins(ctx, s.client, needViewer)
+		if err != nil {
+			return cfg, false, err
+		}
+		s.viewerVars = viewerVars
+	}
+	globals := MergeVars(timeBuiltins(s.clock.Now()), s.viewerVars, cfg.GlobalVars(s.vars))
+
+	s.live = nil
+	if lister, ok := s.client.(SavedSearchLister); ok && s.mode == ModeSync {
+		existing, err := lister.ListSavedSearches(ctx)
+		switch {
+		case errors.Is(err, ErrListUnavailable):
+			// Without the live searches every tracked one is updated.
+		case err != nil:
+			return cfg, false, fmt.Errorf("list saved searches: %w", err)
+		default:
+			s.live = make(map[string]SavedSearch, len(existing))
+			for _, ss := range existing {
+				s.live[ss.ID] = ss
+			}
+		}
+	}
+
 	updated := false
 	for i := range cfg.Searches {
 		search := &cfg.Searches[i]
+		if accounts[i] != s.account {
+			continue
+		}
+		if search.IDAccount != s.account {
+			search.ID, search.IDs = "", nil
+			search.IDAccount = s.account
+			updated = true
+		}
+
+		if search.IsExpanded() {
+			changed, err := s.syncExpanded(ctx, search, cfg.Templates, globals)
+			if changed {
+				updated = true
+			}
+			if err != nil {
+				return cfg, updated, err
+			}
+			continue
+		}
 
 		if search.Name != "" {
-			fmt.Println("Processing: " + search.Name)
+			s.emit(Event{Kind: EventEntryStarted, Name: search.Name, ID: search.ID})
 		}
 
-		query, err := RenderQuery(*search, cfg.Templates)
+		query, err := renderQueryAt(s.clock, WithGlobalVars(*search, globals), cfg.Templates)
 		if err != nil {
-			return fmt.Errorf("%s: %w", search.Name, err)
+			return cfg, updated, s.fail(search.Name, fmt.Errorf("%s: %w", search.Name, err))
 		}
 
-		name := search.Name
-		if name == "" && search.Section != "" {
-			name = fmt.Sprintf("== %s ==", search.Section)
-		}
-		if name == "" {
-			return fmt.Errorf("search entry missing name")
+		name, err := displayName(*search)
+		if err != nil {
+			return cfg, updated, s.fail(search.Name, err)
 		}
 
 		input := SavedSearchInput{
@@ -51,58 +280,279 @@ func (s *Syncer) Sync(ctx context.Context, configPath string) error {
 			Query: query,
 		}
 
-		if search.Remove {
-			if search.ID != "" {
-				if err := s.client.DeleteSavedSearch(ctx, search.ID); err != nil {
-					return fmt.Errorf("delete %s: %w", search.Name, err)
-				}
-				search.ID = ""
+		id, changed, err := s.apply(ctx, search.Name, search.ID, input, search.Remove)
+		if changed {
+			search.ID = id
+			if search.Remove {
 				search.Remove = false
-				updated = true
 			}
-			continue
+			updated = true
 		}
-
-		if s.reset {
-			if search.ID != "" {
-				if err := s.client.DeleteSavedSearch(ctx, search.ID); err != nil {
-					return fmt.Errorf("reset delete %s: %w", search.Name, err)
-				}
-				search.ID = ""
-				updated = true
-			}
-			continue
+		if err != nil {
+			return cfg, updated, err
 		}
+	}
+
+	if s.dryRun {
+		updated = false
+	}
+	return cfg, updated, nil
+}
 
-		if s.recreate && search.ID != "" {
-			if err := s.client.DeleteSavedSearch(ctx, search.ID); err != nil {
-				return fmt.Errorf("force delete %s: %w", search.Name, err)
+// cloneSearches copies searches deeply enough that recording IDs doesn't
+// touch the caller's config.
+func cloneSearches(searches []SearchDefinition) []SearchDefinition {
+	out := make([]SearchDefinition, len(searches))
+	for i, search := range searches {
+		if search.IDs != nil {
+			ids := make(map[string]string, len(search.IDs))
+			for k, v := range search.IDs {
+				ids[k] = v
 			}
-			search.ID = ""
-			updated = true
+			search.IDs = ids
 		}
+		out[i] = search
+	}
+	return out
+}
 
-		if search.ID == "" {
-			id, err := s.client.CreateSavedSearch(ctx, input)
-			if err != nil {
-				return fmt.Errorf("create %s: %w", search.Name, err)
-			}
-			search.ID = id
-			updated = true
+// syncExpanded reconciles every search generated by a matrix or for_each
+// entry, deleting searches whose combination no longer exists.
+func (s *Syncer) syncExpanded(ctx context.Context, search *SearchDefinition, templates map[string]TemplateDefinition, globals map[string]any) (bool, error) {
+	expansions, err := expandSearchAt(s.clock, WithGlobalVars(*search, globals))
+	if err != nil {
+		return false, s.fail(search.Name, fmt.Errorf("%s: %w", search.Name, err))
+	}
+
+	updated := false
+	setID := func(key, id string) {
+		if id == "" {
+			delete(search.IDs, key)
 		} else {
-			if err := s.client.UpdateSavedSearch(ctx, search.ID, input); err != nil {
-				return fmt.Errorf("update %s: %w", search.Name, err)
+			if search.IDs == nil {
+				search.IDs = map[string]string{}
 			}
+			search.IDs[key] = id
+		}
+		updated = true
+	}
+
+	current := map[string]bool{}
+	for _, exp := range expansions {
+		current[exp.Key] = true
+		def := exp.Definition
+
+		if exp.LegacyKey != "" {
+			delete(search.IDs, exp.LegacyKey)
+			setID(exp.Key, def.ID)
 		}
 
-		time.Sleep(1 * time.Second)
+		s.emit(Event{Kind: EventEntryStarted, Name: def.Name, ID: def.ID})
+
+		query, err := renderQueryAt(s.clock, def, templates)
+		if err != nil {
+			return updated, s.fail(def.Name, fmt.Errorf("%s: %w", def.Name, err))
+		}
+
+		input := SavedSearchInput{Name: def.Name, Query: query}
+		id, changed, err := s.apply(ctx, def.Name, def.ID, input, def.Remove)
+		if changed {
+			setID(exp.Key, id)
+		}
+		if err != nil {
+			return updated, err
+		}
 	}
 
-	if updated {
-		if err := SaveConfig(configPath, cfg); err != nil {
-			return err
+	stale := make([]string, 0, len(search.IDs))
+	for key := range search.IDs {
+		if !current[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	for _, key := range stale {
+		id := search.IDs[key]
+		s.emit(Event{Kind: EventActionPlanned, Name: key, ID: id, Action: ActionDelete})
+		start := s.clock.Now()
+		err := s.delete(ctx, key, id)
+		if err != nil {
+			err = fmt.Errorf("delete %s: %w", key, err)
 		}
+		s.record(EntryResult{Name: key, ID: id, Action: ActionDelete}, start, err)
+		if err != nil {
+			return updated, err
+		}
+		setID(key, "")
 	}
 
-	return nil
+	if search.Remove && updated && len(search.IDs) == 0 {
+		search.Remove = false
+		updated = true
+	}
+
+	return updated, nil
+}
+
+// apply reconciles a single rendered search with GitHub and records the
+// outcome. It returns the id that should be stored for the search and
+// whether it changed.
+func (s *Syncer) apply(ctx context.Context, label, id string, input SavedSearchInput, remove bool) (string, bool, error) {
+	start := s.clock.Now()
+	result := EntryResult{Name: input.Name, ID: id, After: input.Query}
+	if live, ok := s.live[id]; ok {
+		result.Before = live.Query
+	}
+
+	newID, changed, action, err := s.reconcile(ctx, label, id, input, remove)
+	if action == "" && err == nil {
+		return newID, changed, nil
+	}
+
+	result.Action = action
+	if action == ActionDelete {
+		result.Before, result.After = input.Query, ""
+	}
+	if newID != "" {
+		result.ID = newID
+	}
+	s.record(result, start, err)
+
+	return newID, changed, err
+}
+
+// reconcile does the work for apply. It returns an empty action when there
+// was nothing to do.
+func (s *Syncer) reconcile(ctx context.Context, label, id string, input SavedSearchInput, remove bool) (string, bool, Action, error) {
+	if remove {
+		if id == "" {
+			return "", false, "", nil
+		}
+		s.emit(Event{Kind: EventActionPlanned, Name: input.Name, ID: id, Action: ActionDelete})
+		if err := s.delete(ctx, input.Name, id); err != nil {
+			return id, false, ActionDelete, fmt.Errorf("delete %s: %w", label, err)
+		}
+		return "", true, ActionDelete, nil
+	}
+
+	if s.mode == ModeReset {
+		if id == "" {
+			return "", false, "", nil
+		}
+		s.emit(Event{Kind: EventActionPlanned, Name: input.Name, ID: id, Action: ActionDelete})
+		if err := s.delete(ctx, input.Name, id); err != nil {
+			return id, false, ActionDelete, fmt.Errorf("reset delete %s: %w", label, err)
+		}
+		return "", true, ActionDelete, nil
+	}
+
+	action := ActionUpdate
+	switch {
+	case id == "":
+		action = ActionCreate
+	case s.mode == ModeRecreate:
+		action = ActionRecreate
+	}
+
+	if action == ActionUpdate {
+		if live, ok := s.live[id]; ok && live.Name == input.Name && query.Format(live.Query) == input.Query {
+			action = ActionUnchanged
+		}
+	}
+
+	diags := query.Lint(input.Query)
+	s.emit(Event{Kind: EventActionPlanned, Name: input.Name, ID: id, Action: action, Diagnostics: diags})
+	if s.strict && query.HasErrors(diags) {
+		return id, false, action, fmt.Errorf("lint %s: query %q has errors", label, input.Query)
+	}
+	if action == ActionUnchanged {
+		return id, false, action, nil
+	}
+
+	if s.dryRun {
+		return id, false, action, nil
+	}
+
+	changed := false
+	if action == ActionRecreate {
+		if err := s.delete(ctx, input.Name, id); err != nil {
+			return id, false, action, fmt.Errorf("force delete %s: %w", label, err)
+		}
+		id = ""
+		changed = true
+	}
+
+	if id == "" {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return "", changed, action, err
+		}
+		start := s.clock.Now()
+		newID, err := s.client.CreateSavedSearch(ctx, input)
+		s.emit(Event{Kind: EventAPICall, Name: input.Name, ID: newID, Action: action, Call: "create", Duration: s.clock.Now().Sub(start), Err: err})
+		if err != nil {
+			return "", changed, action, fmt.Errorf("create %s: %w", label, err)
+		}
+		id = newID
+		changed = true
+	} else {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return id, changed, action, err
+		}
+		start := s.clock.Now()
+		err := s.client.UpdateSavedSearch(ctx, id, input)
+		s.emit(Event{Kind: EventAPICall, Name: input.Name, ID: id, Action: action, Call: "update", Duration: s.clock.Now().Sub(start), Err: err})
+		if err != nil {
+			return id, changed, action, fmt.Errorf("update %s: %w", label, err)
+		}
+	}
+
+	return id, changed, action, nil
+}
+
+// delete removes a saved search, unless this is a dry run.
+func (s *Syncer) delete(ctx context.Context, name, id string) error {
+	if s.dryRun {
+		return nil
+	}
+	if err := s.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	start := s.clock.Now()
+	err := s.client.DeleteSavedSearch(ctx, id)
+	s.emit(Event{Kind: EventAPICall, Name: name, ID: id, Action: ActionDelete, Call: "delete", Duration: s.clock.Now().Sub(start), Err: err})
+	return err
+}
+
+// record appends the outcome of one entry to the results.
+func (s *Syncer) record(result EntryResult, start time.Time, err error) {
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Account = s.account
+	result.DurationMS = s.clock.Now().Sub(start).Milliseconds()
+	s.results = append(s.results, result)
+
+	s.emit(Event{Kind: EventEntryFinished, Name: result.Name, ID: result.ID, Action: result.Action, Result: &result})
+	if err != nil {
+		s.emit(Event{Kind: EventError, Name: result.Name, ID: result.ID, Action: result.Action, Err: err})
+	}
+}
+
+// fail records and reports an error for an entry that failed before it had
+// an action.
+func (s *Syncer) fail(name string, err error) error {
+	s.results = append(s.results, EntryResult{Name: name, Account: s.account, Error: err.Error()})
+	s.emit(Event{Kind: EventError, Name: name, Err: err})
+	return err
+}
+
+// emit sends an event to the observers and the logger.
+func (s *Syncer) emit(e Event) {
+	e.DryRun = s.dryRun
+	for _, o := range s.observers {
+		o.OnEvent(e)
+	}
+	if s.logger != nil {
+		logEvent(s.logger, e)
+	}
 }
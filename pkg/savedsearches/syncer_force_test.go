@@ -21,7 +21,7 @@ searches:
 	}
 
 	client := &stubClient{nextID: "SSC_new"}
-	syncer := NewSyncer(client, true, false)
+	syncer := newTestSyncer(client, WithMode(ModeRecreate))
 	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
 		t.Fatalf("sync: %v", err)
 	}
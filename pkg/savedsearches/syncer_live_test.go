@@ -0,0 +1,91 @@
+package savedsearches
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type listingClient struct {
+	stubClient
+	live    []SavedSearch
+	listErr error
+}
+
+func (l *listingClient) ListSavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	return l.live, l.listErr
+}
+
+func TestSyncerSkipsUnchangedSearches(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+searches:
+  - name: Same
+    id: SSC_same
+    query: "state:open   is:pr"
+  - name: Changed
+    id: SSC_changed
+    query: "is:pr state:closed"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &listingClient{live: []SavedSearch{
+		{ID: "SSC_same", Name: "Same", Query: "is:pr  state:open"},
+		{ID: "SSC_changed", Name: "Changed", Query: "is:pr state:open"},
+	}}
+	syncer := newTestSyncer(client)
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if len(client.updated) != 1 || client.updated[0].Name != "Changed" {
+		t.Fatalf("expected only the changed search to be updated, got %+v", client.updated)
+	}
+}
+
+func TestSyncerResultsRecordBeforeAndAfter(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+searches:
+  - name: Changed
+    id: SSC_changed
+    query: "is:pr state:closed"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &listingClient{live: []SavedSearch{
+		{ID: "SSC_changed", Name: "Changed", Query: "is:pr state:open"},
+	}}
+	syncer := newTestSyncer(client)
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	results := syncer.Results()
+	if len(results) != 1 {
+		t.Fatalf("expected one result, got %+v", results)
+	}
+	r := results[0]
+	if r.Action != ActionUpdate || r.ID != "SSC_changed" || r.Before != "is:pr state:open" || r.After != "is:pr state:closed" {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
+
+func TestSyncerWithoutListQueryUpdatesEverything(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{{Name: "Same", ID: "SSC_same", Query: "is:pr state:open"}}}
+
+	client := &listingClient{listErr: ErrListUnavailable}
+	if _, _, err := newTestSyncer(client).SyncConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("expected a missing list query not to fail the sync, got %v", err)
+	}
+	if len(client.updated) != 1 {
+		t.Fatalf("expected the search to be updated, got %+v", client.updated)
+	}
+}
@@ -0,0 +1,86 @@
+package savedsearches
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncerMatrixCreatesAndDeletes(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+searches:
+  - name: "Work from {{ user }}"
+    template: recent
+    matrix:
+      user: [alice, carol]
+    ids:
+      Work from alice: SSC_alice
+      Work from bob: SSC_bob
+templates:
+  recent:
+    query: "assignee:{{ user }}"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &stubClient{nextID: "SSC_carol"}
+	syncer := newTestSyncer(client)
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if len(client.updated) != 1 || client.updated[0].Query != "assignee:alice" {
+		t.Fatalf("expected update for alice, got %+v", client.updated)
+	}
+	if len(client.created) != 1 || client.created[0].Name != "Work from carol" {
+		t.Fatalf("expected create for carol, got %+v", client.created)
+	}
+	if len(client.deleted) != 1 || client.deleted[0] != "SSC_bob" {
+		t.Fatalf("expected delete of bob, got %+v", client.deleted)
+	}
+
+	updatedCfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("reload cfg: %v", err)
+	}
+	ids := updatedCfg.Searches[0].IDs
+	if len(ids) != 2 || ids["user=alice"] != "SSC_alice" || ids["user=carol"] != "SSC_carol" {
+		t.Fatalf("unexpected ids persisted: %+v", ids)
+	}
+	if updatedCfg.Searches[0].Name != "Work from {{ user }}" {
+		t.Fatalf("expected name template kept, got %s", updatedCfg.Searches[0].Name)
+	}
+}
+
+func TestSyncerMatrixRenameKeepsIDs(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+searches:
+  - name: "PRs by {{ user }}"
+    query: "is:pr author:{{ user }}"
+    matrix:
+      user: [alice]
+    ids:
+      user=alice: SSC_alice
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &stubClient{}
+	if err := newTestSyncer(client).Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if len(client.created) != 0 || len(client.deleted) != 0 {
+		t.Fatalf("expected no creates or deletes, got %+v %+v", client.created, client.deleted)
+	}
+	if len(client.updated) != 1 || client.updated[0].Name != "PRs by alice" {
+		t.Fatalf("expected the renamed search to be updated, got %+v", client.updated)
+	}
+}
@@ -23,7 +23,7 @@ searches:
 	}
 
 	client := &stubClient{}
-	syncer := NewSyncer(client, false, true)
+	syncer := newTestSyncer(client, WithMode(ModeReset))
 	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
 		t.Fatalf("sync: %v", err)
 	}
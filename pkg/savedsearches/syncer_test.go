@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -13,6 +14,14 @@ type stubClient struct {
 	deleted []string
 	nextID  string
 	err     error
+
+	viewer      Viewer
+	viewerCalls int
+}
+
+func (s *stubClient) Viewer(ctx context.Context) (Viewer, error) {
+	s.viewerCalls++
+	return s.viewer, nil
 }
 
 func (s *stubClient) CreateSavedSearch(ctx context.Context, input SavedSearchInput) (string, error) {
@@ -65,7 +74,7 @@ searches:
 	}
 
 	client := &stubClient{nextID: "SSC_new"}
-	syncer := NewSyncer(client, false, false)
+	syncer := newTestSyncer(client)
 	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
 		t.Fatalf("sync: %v", err)
 	}
@@ -116,3 +125,149 @@ searches:
 		t.Fatalf("expected removed id cleared, got %s", updatedCfg.Searches[4].ID)
 	}
 }
+
+func TestSyncerAppliesGlobalVars(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+vars:
+  org: Kong
+  user: alice
+searches:
+  - name: Mine
+    query: "org:{{ org }} assignee:{{ user }}"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &stubClient{}
+	syncer := newTestSyncer(client, WithVars(map[string]any{"user": "bob"}))
+	if err := syncer.Sync(context.Background(), cfgPath); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	if len(client.created) != 1 || client.created[0].Query != "assignee:bob org:Kong" {
+		t.Fatalf("expected rendered query, got %+v", client.created)
+	}
+
+	updatedCfg, err := LoadConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("reload cfg: %v", err)
+	}
+	if len(updatedCfg.Searches[0].Vars) != 0 {
+		t.Fatalf("expected globals not written into entries, got %+v", updatedCfg.Searches[0].Vars)
+	}
+}
+
+func TestSyncerPlanMakesNoChanges(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cfgYAML := `
+searches:
+  - name: Create
+    query: is:issue state:open
+  - name: Update
+    id: SSC_update
+    query: is:pr state:open
+  - name: Remove
+    id: SSC_remove
+    query: is:issue
+    remove: true
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgYAML), 0o600); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	client := &stubClient{}
+	syncer := newTestSyncer(client)
+	results, err := syncer.Plan(context.Background(), cfgPath)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+
+	if len(client.created)+len(client.updated)+len(client.deleted) != 0 {
+		t.Fatalf("plan called the client: %+v", client)
+	}
+	for i := range results {
+		results[i].DurationMS = 0
+	}
+	want := []EntryResult{
+		{Name: "Create", Action: ActionCreate, After: "is:issue state:open"},
+		{Name: "Update", ID: "SSC_update", Action: ActionUpdate, After: "is:pr state:open"},
+		{Name: "Remove", ID: "SSC_remove", Action: ActionDelete, Before: "is:issue"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("unexpected plan:\n got %+v\nwant %+v", results, want)
+	}
+
+	raw, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("read cfg: %v", err)
+	}
+	if string(raw) != cfgYAML {
+		t.Fatalf("plan rewrote the config:\n%s", raw)
+	}
+}
+
+func TestSyncConfigDatesUseClock(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{
+		{Name: "Recent", Query: `is:issue created:{{ today }} updated:>{{ ago("0d") }}`},
+		{Name: "Due {{ date(\"+1d\") }}", Query: "is:issue", ForEach: []map[string]any{{"n": 1}}},
+	}}
+
+	client := &stubClient{nextID: "SSC_new"}
+	if _, _, err := newTestSyncer(client).SyncConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if len(client.created) != 2 || client.created[0].Query != "created:2026-10-16 is:issue updated:>2026-10-16" || client.created[1].Name != "Due 2026-10-17" {
+		t.Fatalf("expected dates from the syncer's clock, got %+v", client.created)
+	}
+}
+
+func TestSyncConfigWithoutDisk(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{
+		{Name: "Create", Query: "is:issue state:open"},
+		{Name: "Work from {{ user }}", Query: "is:pr author:{{ user }}", Matrix: map[string][]any{"user": {"alice"}}, IDs: map[string]string{"Work from bob": "SSC_bob"}},
+	}}
+
+	client := &stubClient{nextID: "SSC_new"}
+	got, updated, err := newTestSyncer(client).SyncConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if !updated {
+		t.Fatalf("expected the config to be updated")
+	}
+	if got.Searches[0].ID != "SSC_new" || !reflect.DeepEqual(got.Searches[1].IDs, map[string]string{"user=alice": "SSC_new"}) {
+		t.Fatalf("unexpected ids: %+v", got.Searches)
+	}
+	if cfg.Searches[0].ID != "" || !reflect.DeepEqual(cfg.Searches[1].IDs, map[string]string{"Work from bob": "SSC_bob"}) {
+		t.Fatalf("input config was modified: %+v", cfg.Searches)
+	}
+}
+
+func TestSyncConfigDryRun(t *testing.T) {
+	cfg := Config{Searches: []SearchDefinition{
+		{Name: "Create", Query: "is:issue state:open"},
+		{Name: "Update", ID: "SSC_update", Query: "is:issue state:closed"},
+	}}
+
+	client := &stubClient{}
+	syncer := newTestSyncer(client, WithDryRun(true), WithMode(ModeRecreate))
+	got, updated, err := syncer.SyncConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if updated || !reflect.DeepEqual(got, cfg) {
+		t.Fatalf("dry run changed the config: %+v", got)
+	}
+	if len(client.created)+len(client.updated)+len(client.deleted) != 0 {
+		t.Fatalf("dry run called the client: %+v", client)
+	}
+
+	results := syncer.Results()
+	if len(results) != 2 || results[0].Action != ActionCreate || results[1].Action != ActionRecreate {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
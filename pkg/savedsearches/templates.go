@@ -0,0 +1,94 @@
+package savedsearches
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateResolver renders templates that extend or include other templates,
+// tracking the chain in progress so cycles are reported instead of recursing.
+type templateResolver struct {
+	templates map[string]TemplateDefinition
+	clock     Clock
+	stack     []string
+	err       error
+}
+
+// render executes the named template, including its extends chain.
+func (r *templateResolver) render(name string, vars map[string]any) (string, error) {
+	if err := r.enter(name); err != nil {
+		return "", err
+	}
+	defer r.leave()
+
+	text, err := r.source(name)
+	if err != nil {
+		return "", err
+	}
+
+	return r.renderText(name, text, vars)
+}
+
+// renderText executes text with fragment support.
+func (r *templateResolver) renderText(name, text string, vars map[string]any) (string, error) {
+	out, err := renderTemplate(name, text, vars, r.clock, template.FuncMap{
+		"fragment": func(fragment string) (string, error) {
+			rendered, err := r.render(fragment, vars)
+			if err != nil && r.err == nil {
+				r.err = err
+			}
+			return rendered, err
+		},
+	})
+	if r.err != nil {
+		return "", r.err
+	}
+	return out, err
+}
+
+// source resolves the extends chain for a template, returning the parent
+// queries followed by the template's own query.
+func (r *templateResolver) source(name string) (string, error) {
+	var parts []string
+	seen := map[string]bool{}
+	chain := []string{}
+	for current := name; current != ""; {
+		chain = append(chain, current)
+		if seen[current] {
+			return "", fmt.Errorf("template extends cycle: %s", strings.Join(chain, " -> "))
+		}
+		seen[current] = true
+
+		tpl, ok := r.templates[current]
+		if !ok {
+			return "", fmt.Errorf("template %q extends unknown template %q", chain[len(chain)-2], current)
+		}
+		if q := strings.TrimSpace(tpl.Query); q != "" {
+			parts = append([]string{q}, parts...)
+		}
+		current = tpl.Extends
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func (r *templateResolver) enter(name string) error {
+	for _, n := range r.stack {
+		if n == name {
+			return fmt.Errorf("template fragment cycle: %s -> %s", strings.Join(r.stack, " -> "), name)
+		}
+	}
+	if _, ok := r.templates[name]; !ok {
+		if len(r.stack) > 0 {
+			return fmt.Errorf("template %q references unknown fragment %q", r.stack[len(r.stack)-1], name)
+		}
+		return fmt.Errorf("template %q not found", name)
+	}
+	r.stack = append(r.stack, name)
+	return nil
+}
+
+func (r *templateResolver) leave() {
+	r.stack = r.stack[:len(r.stack)-1]
+}
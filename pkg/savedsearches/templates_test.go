@@ -0,0 +1,82 @@
+package savedsearches
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderQueryExtends(t *testing.T) {
+	cfg := map[string]TemplateDefinition{
+		"base":  {Query: "archived:false"},
+		"mine":  {Extends: "base", Query: "assignee:{{ user }}"},
+		"child": {Extends: "mine", Query: "is:pr"},
+	}
+
+	query, err := RenderQuery(SearchDefinition{
+		Template: "child",
+		Vars:     map[string]any{"user": "alice"},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "archived:false assignee:alice is:pr"
+	if query != want {
+		t.Fatalf("expected %s, got %s", want, query)
+	}
+}
+
+func TestRenderQueryFragment(t *testing.T) {
+	cfg := map[string]TemplateDefinition{
+		"no-bots": {Query: "archived:false -author:app/renovate"},
+		"mine":    {Query: "is:pr author:{{ user }} {{ fragment \"no-bots\" }}"},
+	}
+
+	query, err := RenderQuery(SearchDefinition{
+		Template: "mine",
+		Vars:     map[string]any{"user": "alice"},
+	}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "archived:false author:alice -author:app/renovate is:pr"
+	if query != want {
+		t.Fatalf("expected %s, got %s", want, query)
+	}
+}
+
+func TestRenderQueryExtendsCycle(t *testing.T) {
+	cfg := map[string]TemplateDefinition{
+		"a": {Extends: "b", Query: "a"},
+		"b": {Extends: "a", Query: "b"},
+	}
+
+	_, err := RenderQuery(SearchDefinition{Template: "a"}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestRenderQueryFragmentCycle(t *testing.T) {
+	cfg := map[string]TemplateDefinition{
+		"a": {Query: "{{ fragment \"b\" }}"},
+		"b": {Query: "{{ fragment \"a\" }}"},
+	}
+
+	_, err := RenderQuery(SearchDefinition{Template: "a"}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "template fragment cycle: a -> b -> a") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestRenderQueryUnknownFragment(t *testing.T) {
+	cfg := map[string]TemplateDefinition{
+		"a": {Query: "{{ fragment \"missing\" }}"},
+	}
+
+	_, err := RenderQuery(SearchDefinition{Template: "a"}, cfg)
+	if err == nil || !strings.Contains(err.Error(), `unknown fragment "missing"`) {
+		t.Fatalf("expected unknown fragment error, got %v", err)
+	}
+}
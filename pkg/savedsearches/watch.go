@@ -0,0 +1,319 @@
+package savedsearches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Notification is a search result that wasn't there on the previous check.
+type Notification struct {
+	Search string     `json:"search"`
+	Item   SearchItem `json:"item"`
+}
+
+// Sink delivers notifications.
+type Sink interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// WriterSink prints one line per notification.
+type WriterSink struct {
+	W io.Writer
+}
+
+// Notify implements Sink.
+func (s WriterSink) Notify(ctx context.Context, n Notification) error {
+	_, err := fmt.Fprintf(s.W, "[%s] %s#%d %s (%s)\n", n.Search, n.Item.Repo, n.Item.Number, n.Item.Title, n.Item.URL)
+	return err
+}
+
+// CommandSink runs a shell command per notification, passing the
+// notification as JSON on stdin and the main fields as environment vars.
+type CommandSink struct {
+	Command string
+}
+
+// Notify implements Sink.
+func (s CommandSink) Notify(ctx context.Context, n Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.Command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"SAVED_SEARCH_NAME="+n.Search,
+		"SAVED_SEARCH_URL="+n.Item.URL,
+		"SAVED_SEARCH_TITLE="+n.Item.Title,
+		"SAVED_SEARCH_REPO="+n.Item.Repo,
+		fmt.Sprintf("SAVED_SEARCH_NUMBER=%d", n.Item.Number),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run hook: %w", err)
+	}
+	return nil
+}
+
+// WebhookSink posts each notification as JSON to a URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify implements Sink.
+func (s WebhookSink) Notify(ctx context.Context, n Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// watchRetention is how long a URL is remembered after it was last seen in a
+// search's results. Items that drop out of the top results and come back
+// within this window don't trigger a second notification.
+const watchRetention = 30 * 24 * time.Hour
+
+// SeenURLs maps each result URL of a search to when it was last seen.
+type SeenURLs map[string]time.Time
+
+// UnmarshalJSON implements json.Unmarshaler. It also accepts the older state
+// format, a plain list of URLs, whose entries get a zero timestamp.
+func (s *SeenURLs) UnmarshalJSON(data []byte) error {
+	var urls []string
+	if err := json.Unmarshal(data, &urls); err == nil {
+		*s = make(SeenURLs, len(urls))
+		for _, url := range urls {
+			(*s)[url] = time.Time{}
+		}
+		return nil
+	}
+
+	var seen map[string]time.Time
+	if err := json.Unmarshal(data, &seen); err != nil {
+		return err
+	}
+	*s = seen
+	return nil
+}
+
+// WatchState records the result URLs seen for each search.
+type WatchState struct {
+	Searches map[string]SeenURLs `json:"searches"`
+}
+
+// ResolveWatchStatePath chooses the watch state file based on flags and env.
+func ResolveWatchStatePath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return expandPath(flagValue)
+	}
+
+	if xdgState := os.Getenv("XDG_STATE_HOME"); xdgState != "" {
+		return filepath.Join(xdgState, "gh-saved-issues", "watch.json"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locate home dir: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "state", "gh-saved-issues", "watch.json"), nil
+}
+
+// LoadWatchState reads the state file. A missing file is an empty state.
+func LoadWatchState(path string) (WatchState, error) {
+	state := WatchState{Searches: map[string]SeenURLs{}}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("read watch state: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return state, fmt.Errorf("parse watch state: %w", err)
+	}
+	if state.Searches == nil {
+		state.Searches = map[string]SeenURLs{}
+	}
+	return state, nil
+}
+
+// SaveWatchState writes the state file.
+func SaveWatchState(path string, state WatchState) error {
+	out, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal watch state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ensure state dir: %w", err)
+	}
+
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		return fmt.Errorf("write watch state: %w", err)
+	}
+	return nil
+}
+
+// Watcher evaluates searches and notifies sinks about new results.
+type Watcher struct {
+	searcher  Searcher
+	statePath string
+	sinks     []Sink
+	limit     int
+	clock     Clock
+	accounts  map[string]Searcher
+}
+
+// NewWatcher constructs a Watcher that looks at the first limit results of
+// each search.
+func NewWatcher(searcher Searcher, statePath string, limit int, sinks ...Sink) *Watcher {
+	return &Watcher{searcher: searcher, statePath: statePath, limit: limit, sinks: sinks, clock: realClock{}}
+}
+
+// SetAccountSearcher makes the watcher run the searches of the named
+// account, "" for the default one, with searcher.
+func (w *Watcher) SetAccountSearcher(account string, searcher Searcher) {
+	if w.accounts == nil {
+		w.accounts = map[string]Searcher{}
+	}
+	w.accounts[account] = searcher
+}
+
+// watchName identifies a search in the state and in notifications. Names
+// are only unique per account, so searches of a named account carry it.
+func watchName(s RenderedSearch) string {
+	if s.Account == "" {
+		return s.Name
+	}
+	return s.Name + " (" + s.Account + ")"
+}
+
+// Check runs every search once, notifies sinks about results that haven't
+// been seen before and saves the new state. Seen URLs are kept until they
+// have been out of the results for watchRetention, so an item that drops out
+// of the first limit results and comes back isn't reported again. The first
+// check of a search only records its results, so existing items don't
+// trigger notifications. An item is only recorded as seen once every sink
+// has accepted it; otherwise it is reported again on the next check.
+func (w *Watcher) Check(ctx context.Context, searches []RenderedSearch) ([]Notification, error) {
+	state, err := LoadWatchState(w.statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.clock.Now()
+	next := WatchState{Searches: map[string]SeenURLs{}}
+	var notifications []Notification
+	var errs []error
+	for _, s := range searches {
+		if s.Header {
+			continue
+		}
+
+		name := watchName(s)
+		searcher := w.searcher
+		if as, ok := w.accounts[s.Account]; ok {
+			searcher = as
+		}
+		if searcher == nil {
+			errs = append(errs, fmt.Errorf("%s: no client for account %q", name, s.Account))
+			continue
+		}
+
+		result, err := searcher.Search(ctx, s.Query, w.limit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			if prev, ok := state.Searches[name]; ok {
+				next.Searches[name] = prev
+			}
+			continue
+		}
+
+		prev, known := state.Searches[name]
+		seen := make(SeenURLs, len(prev)+len(result.Items))
+		for url, at := range prev {
+			// Entries from the older list format have no timestamp; start
+			// their retention window now.
+			if at.IsZero() {
+				at = now
+			}
+			if now.Sub(at) <= watchRetention {
+				seen[url] = at
+			}
+		}
+
+		for _, item := range result.Items {
+			if _, ok := seen[item.URL]; known && !ok {
+				notifications = append(notifications, Notification{Search: name, Item: item})
+			}
+			seen[item.URL] = now
+		}
+		next.Searches[name] = seen
+	}
+
+	for _, n := range notifications {
+		delivered := true
+		for _, sink := range w.sinks {
+			if err := sink.Notify(ctx, n); err != nil {
+				errs = append(errs, err)
+				delivered = false
+			}
+		}
+		// Leave an item a sink failed to take unseen, so the next check
+		// retries it.
+		if !delivered {
+			delete(next.Searches[n.Search], n.Item.URL)
+		}
+	}
+
+	if err := SaveWatchState(w.statePath, next); err != nil {
+		errs = append(errs, err)
+	}
+
+	return notifications, errors.Join(errs...)
+}
@@ -0,0 +1,235 @@
+package savedsearches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type resultSearcher struct {
+	results map[string]SearchResult
+}
+
+func (s *resultSearcher) Search(ctx context.Context, q string, limit int) (SearchResult, error) {
+	return s.results[q], nil
+}
+
+type recordingSink struct {
+	got []Notification
+	err error
+}
+
+func (s *recordingSink) Notify(ctx context.Context, n Notification) error {
+	s.got = append(s.got, n)
+	return s.err
+}
+
+func TestWatcherNotifiesNewItems(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state", "watch.json")
+	searcher := &resultSearcher{results: map[string]SearchResult{
+		"is:pr": {Items: []SearchItem{{Number: 1, URL: "https://github.com/a/b/pull/1"}}},
+	}}
+	sink := &recordingSink{}
+	watcher := NewWatcher(searcher, statePath, 50, sink)
+	searches := []RenderedSearch{{Name: "== Team ==", Header: true}, {Name: "PRs", Query: "is:pr"}}
+
+	if _, err := watcher.Check(context.Background(), searches); err != nil {
+		t.Fatalf("first check: %v", err)
+	}
+	if len(sink.got) != 0 {
+		t.Fatalf("expected first check to only record state, got %+v", sink.got)
+	}
+
+	searcher.results["is:pr"] = SearchResult{Items: []SearchItem{
+		{Number: 2, URL: "https://github.com/a/b/pull/2", Title: "New"},
+		{Number: 1, URL: "https://github.com/a/b/pull/1"},
+	}}
+	notifications, err := watcher.Check(context.Background(), searches)
+	if err != nil {
+		t.Fatalf("second check: %v", err)
+	}
+	if len(notifications) != 1 || len(sink.got) != 1 || sink.got[0].Item.Number != 2 || sink.got[0].Search != "PRs" {
+		t.Fatalf("expected notification for #2, got %+v", sink.got)
+	}
+
+	state, err := LoadWatchState(statePath)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(state.Searches["PRs"]) != 2 {
+		t.Fatalf("expected state updated, got %+v", state)
+	}
+}
+
+func TestWatcherRetriesFailedDeliveries(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+	searcher := &resultSearcher{results: map[string]SearchResult{
+		"is:pr": {Items: []SearchItem{{Number: 1, URL: "https://github.com/a/b/pull/1"}}},
+	}}
+	ok, failing := &recordingSink{}, &recordingSink{err: errors.New("webhook returned 502")}
+	watcher := NewWatcher(searcher, statePath, 50, ok, failing)
+	searches := []RenderedSearch{{Name: "PRs", Query: "is:pr"}}
+
+	if _, err := watcher.Check(context.Background(), searches); err != nil {
+		t.Fatalf("first check: %v", err)
+	}
+
+	searcher.results["is:pr"] = SearchResult{Items: []SearchItem{
+		{Number: 2, URL: "https://github.com/a/b/pull/2"},
+		{Number: 1, URL: "https://github.com/a/b/pull/1"},
+	}}
+	if _, err := watcher.Check(context.Background(), searches); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected the sink error, got %v", err)
+	}
+
+	failing.err = nil
+	if _, err := watcher.Check(context.Background(), searches); err != nil {
+		t.Fatalf("third check: %v", err)
+	}
+	if len(failing.got) != 2 || failing.got[1].Item.Number != 2 {
+		t.Fatalf("expected #2 to be delivered again, got %+v", failing.got)
+	}
+
+	if _, err := watcher.Check(context.Background(), searches); err != nil {
+		t.Fatalf("fourth check: %v", err)
+	}
+	if len(failing.got) != 2 || len(ok.got) != 2 {
+		t.Fatalf("expected no more notifications once delivered, got %+v %+v", ok.got, failing.got)
+	}
+}
+
+func TestWatcherRemembersItemsOutsideTheLimit(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+	one := SearchItem{Number: 1, URL: "https://github.com/a/b/pull/1"}
+	two := SearchItem{Number: 2, URL: "https://github.com/a/b/pull/2"}
+	searcher := &resultSearcher{results: map[string]SearchResult{"is:pr": {Items: []SearchItem{one}}}}
+	sink := &recordingSink{}
+	clock := newFakeClock()
+	watcher := NewWatcher(searcher, statePath, 1, sink)
+	watcher.clock = clock
+	searches := []RenderedSearch{{Name: "PRs", Query: "is:pr"}}
+
+	check := func() {
+		t.Helper()
+		if _, err := watcher.Check(context.Background(), searches); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+	}
+
+	check()
+	searcher.results["is:pr"] = SearchResult{Items: []SearchItem{two}}
+	check()
+	searcher.results["is:pr"] = SearchResult{Items: []SearchItem{one}}
+	check()
+	if len(sink.got) != 1 || sink.got[0].Item.Number != 2 {
+		t.Fatalf("expected only #2 to be reported, got %+v", sink.got)
+	}
+
+	// Once #2 has been out of the results for longer than the retention
+	// window it is forgotten and reported again if it comes back.
+	clock.Advance(watchRetention / 2)
+	check()
+	clock.Advance(watchRetention/2 + time.Hour)
+	check()
+	searcher.results["is:pr"] = SearchResult{Items: []SearchItem{two}}
+	check()
+	if len(sink.got) != 2 || sink.got[1].Item.Number != 2 {
+		t.Fatalf("expected #2 to be reported again after retention, got %+v", sink.got)
+	}
+}
+
+func TestWatcherUsesAccountSearchers(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+	personal := &resultSearcher{results: map[string]SearchResult{"is:pr": {Items: []SearchItem{{Number: 1, URL: "https://github.com/a/b/pull/1"}}}}}
+	work := &resultSearcher{results: map[string]SearchResult{"is:pr": {Items: []SearchItem{{Number: 2, URL: "https://ghe.example.com/c/d/pull/2"}}}}}
+	watcher := NewWatcher(nil, statePath, 10)
+	watcher.SetAccountSearcher("", personal)
+	watcher.SetAccountSearcher("work", work)
+
+	searches := []RenderedSearch{{Name: "PRs", Query: "is:pr"}, {Name: "PRs", Query: "is:pr", Account: "work"}}
+	if _, err := watcher.Check(context.Background(), searches); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+
+	state, err := LoadWatchState(statePath)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if _, ok := state.Searches["PRs"]["https://github.com/a/b/pull/1"]; !ok {
+		t.Fatalf("expected the default account's results, got %+v", state)
+	}
+	if _, ok := state.Searches["PRs (work)"]["https://ghe.example.com/c/d/pull/2"]; !ok {
+		t.Fatalf("expected the work account's results under their own key, got %+v", state)
+	}
+}
+
+func TestLoadWatchStateReadsURLList(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+	if err := os.WriteFile(statePath, []byte(`{"searches":{"PRs":["https://github.com/a/b/pull/1"]}}`), 0o600); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+
+	state, err := LoadWatchState(statePath)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if _, ok := state.Searches["PRs"]["https://github.com/a/b/pull/1"]; !ok {
+		t.Fatalf("expected the listed URL to be seen, got %+v", state)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got Notification
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected json content type")
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer ts.Close()
+
+	sink := WebhookSink{URL: ts.URL, Client: ts.Client()}
+	n := Notification{Search: "PRs", Item: SearchItem{Number: 3, URL: "https://github.com/a/b/pull/3"}}
+	if err := sink.Notify(context.Background(), n); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if got.Search != "PRs" || got.Item.Number != 3 {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	sink := CommandSink{Command: `echo "$SAVED_SEARCH_NAME #$SAVED_SEARCH_NUMBER" > ` + out + ` && cat >> ` + out}
+	n := Notification{Search: "PRs", Item: SearchItem{Number: 3, Title: "Fix"}}
+	if err := sink.Notify(context.Background(), n); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	raw, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if !strings.HasPrefix(string(raw), "PRs #3\n") || !strings.Contains(string(raw), `"title":"Fix"`) {
+		t.Fatalf("unexpected hook output: %s", raw)
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	n := Notification{Search: "PRs", Item: SearchItem{Number: 3, Repo: "a/b", Title: "Fix", URL: "u"}}
+	if err := (WriterSink{W: &buf}).Notify(context.Background(), n); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if buf.String() != "[PRs] a/b#3 Fix (u)\n" {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
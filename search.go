@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/browser"
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+)
+
+// setupPreview renders a configured search and prints its top results.
+func setupPreview(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	limit := flags.Int("limit", 10, "number of results to show")
+	jsonOut := flags.Bool("json", false, "print results as JSON")
+	return func(ctx context.Context, args []string) int {
+		if len(args) != 1 {
+			flags.Usage()
+			return 2
+		}
+
+		views, err := loadAccountViews(ctx, opts, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		view, search, err := findViewSearch(views, args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		client, err := view.requireClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		result, err := client.Search(ctx, search.Query, *limit)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(struct {
+				Name  string `json:"name"`
+				Query string `json:"query"`
+				savedsearches.SearchResult
+			}{search.Name, search.Query, result}); err != nil {
+				log.Fatal(err)
+			}
+			return 0
+		}
+
+		fmt.Printf("%s: %s\n\n", search.Name, search.Query)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NUMBER\tREPO\tTITLE\tAUTHOR\tUPDATED")
+		for _, item := range result.Items {
+			fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\t%s\n", item.Number, item.Repo, truncate(item.Title, 60), item.Author, item.UpdatedAt.Format("2006-01-02"))
+		}
+		tw.Flush()
+		fmt.Printf("\nShowing %d of %d results\n", len(result.Items), result.Total)
+		return 0
+	}
+}
+
+// setupStatus prints the open result count for every configured search,
+// highlighting those above their warn_above threshold.
+func setupStatus(_ *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	return func(ctx context.Context, _ []string) int {
+		views, err := loadAccountViews(ctx, opts, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var rows []savedsearches.StatusRow
+		for _, view := range views {
+			rows = append(rows, savedsearches.Status(ctx, view.client, view.searches)...)
+		}
+
+		color := colorEnabled()
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSECTION\tOPEN")
+		for _, row := range rows {
+			name := row.Name
+			if row.Account != "" {
+				name += " (" + row.Account + ")"
+			}
+			count := fmt.Sprint(row.Count)
+			switch {
+			case row.Err != nil:
+				count = "error: " + row.Err.Error()
+			case row.Warn():
+				count = fmt.Sprintf("%d (> %d)", row.Count, row.WarnAbove)
+				if color {
+					count = "\033[31m" + count + "\033[0m"
+				}
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", name, row.Section, count)
+		}
+		tw.Flush()
+		return 0
+	}
+}
+
+// setupWatch periodically checks every search and reports new results to
+// stdout and any configured hook or webhook.
+func setupWatch(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	stateFlag := flags.String("state", "", "path to watch state file (default: $XDG_STATE_HOME/gh-saved-issues/watch.json)")
+	interval := flags.Duration("interval", 5*time.Minute, "time between checks")
+	once := flags.Bool("once", false, "check once and exit")
+	limit := flags.Int("limit", 50, "number of results to track per search")
+	hook := flags.String("exec", "", "shell command to run for each new item (item JSON on stdin)")
+	webhook := flags.String("webhook", "", "URL to POST each new item to as JSON")
+	return func(ctx context.Context, _ []string) int {
+		statePath, err := savedsearches.ResolveWatchStatePath(*stateFlag)
+		if err != nil {
+			log.Fatalf("resolve state path: %v", err)
+		}
+
+		sinks := []savedsearches.Sink{savedsearches.WriterSink{W: os.Stdout}}
+		if *hook != "" {
+			sinks = append(sinks, savedsearches.CommandSink{Command: *hook})
+		}
+		if *webhook != "" {
+			sinks = append(sinks, savedsearches.WebhookSink{URL: *webhook})
+		}
+		watcher := savedsearches.NewWatcher(nil, statePath, *limit, sinks...)
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+
+		for {
+			// Reload each time so config edits are picked up without a restart.
+			views, err := loadAccountViews(ctx, opts, true)
+			if err == nil {
+				var searches []savedsearches.RenderedSearch
+				for _, view := range views {
+					watcher.SetAccountSearcher(view.name, view.client)
+					searches = append(searches, view.searches...)
+				}
+				_, err = watcher.Check(ctx, searches)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				if *once {
+					return 1
+				}
+			}
+
+			if *once {
+				return 0
+			}
+
+			select {
+			case <-ctx.Done():
+				return 0
+			case <-time.After(*interval):
+			}
+		}
+	}
+}
+
+// setupExport writes the rendered searches as Markdown, HTML bookmarks, CSV
+// or JSON.
+func setupExport(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	format := flags.String("format", "markdown", "output format: "+strings.Join(savedsearches.ExportFormats, ", "))
+	file := flags.String("file", "", "write to this file instead of stdout")
+	return func(ctx context.Context, _ []string) int {
+		views, err := loadAccountViews(ctx, opts, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Search links point at a single host, so accounts on different
+		// hosts have to be exported one at a time.
+		var searches []savedsearches.RenderedSearch
+		for _, view := range views {
+			if view.host != views[0].host {
+				fmt.Fprintf(os.Stderr, "accounts are on different hosts (%s, %s); export them one at a time with --account\n", views[0].host, view.host)
+				return 1
+			}
+			searches = append(searches, view.searches...)
+		}
+
+		var out io.Writer = os.Stdout
+		if *file != "" {
+			f, err := os.Create(*file)
+			if err != nil {
+				log.Fatalf("create export file: %v", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		if err := savedsearches.Export(out, *format, searches, views[0].host); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+}
+
+// setupOpen opens a configured search on GitHub, or prints its URL.
+func setupOpen(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	printOnly := flags.Bool("print", false, "print the URL instead of opening a browser")
+	return func(ctx context.Context, args []string) int {
+		if len(args) != 1 {
+			flags.Usage()
+			return 2
+		}
+
+		views, err := loadAccountViews(ctx, opts, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		view, search, err := findViewSearch(views, args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		url := savedsearches.SearchURL(view.host, search.Query)
+		if *printOnly {
+			fmt.Println(url)
+			return 0
+		}
+
+		if err := browser.New("", os.Stdout, os.Stderr).Browse(url); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mheap/gh-saved-issues/pkg/query"
+	"github.com/mheap/gh-saved-issues/pkg/savedsearches"
+)
+
+// syncFlags are the flags shared by sync, reset and recreate.
+type syncFlags struct {
+	yes    *bool
+	output *string
+	delay  *time.Duration
+	rps    *float64
+	strict *bool
+}
+
+func newSyncFlags(flags *flag.FlagSet) syncFlags {
+	return syncFlags{
+		yes:    flags.Bool("yes", false, "delete saved searches without asking for confirmation"),
+		output: outputFlag(flags),
+		delay:  flags.Duration("delay", 0, "pause between API writes, on top of --rps"),
+		rps:    flags.Float64("rps", 1, "maximum API writes per second (0 for no limit)"),
+		strict: strictFlag(flags),
+	}
+}
+
+func setupSync(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	// Kept from before sync was a subcommand; prefer the recreate and reset
+	// commands.
+	recreate := flags.Bool("recreate", false, "recreate all saved searches (delete existing first)")
+	reset := flags.Bool("reset", false, "delete configured saved searches without recreating them")
+	sf := newSyncFlags(flags)
+	return func(ctx context.Context, _ []string) int {
+		mode, ok := modeFromFlags(*recreate, *reset)
+		if !ok {
+			return 2
+		}
+		return runSync(ctx, opts, mode, sf)
+	}
+}
+
+func setupReset(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	sf := newSyncFlags(flags)
+	return func(ctx context.Context, _ []string) int {
+		return runSync(ctx, opts, savedsearches.ModeReset, sf)
+	}
+}
+
+func setupRecreate(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	sf := newSyncFlags(flags)
+	return func(ctx context.Context, _ []string) int {
+		return runSync(ctx, opts, savedsearches.ModeRecreate, sf)
+	}
+}
+
+// modeFromFlags maps the --recreate and --reset flags to a sync mode. They
+// can't be combined.
+func modeFromFlags(recreate, reset bool) (savedsearches.Mode, bool) {
+	switch {
+	case recreate && reset:
+		fmt.Fprintln(os.Stderr, "--recreate and --reset can't be used together")
+		return 0, false
+	case recreate:
+		return savedsearches.ModeRecreate, true
+	case reset:
+		return savedsearches.ModeReset, true
+	}
+	return savedsearches.ModeSync, true
+}
+
+func strictFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("strict", false, "fail entries whose queries have lint errors instead of only reporting them")
+}
+
+func outputFlag(flags *flag.FlagSet) *string {
+	return flags.String("output", "text", "result format: "+strings.Join(savedsearches.ReportFormats, ", "))
+}
+
+// validOutput reports whether format is a known report format, printing an
+// error if not. It is checked up front so a typo doesn't cost a whole sync.
+func validOutput(format string) bool {
+	if slices.Contains(savedsearches.ReportFormats, format) {
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "unknown output format %q (want one of %s)\n", format, strings.Join(savedsearches.ReportFormats, ", "))
+	return false
+}
+
+// writeReport prints the sync results to stdout in the chosen format.
+func writeReport(format string, results []savedsearches.EntryResult) error {
+	report := savedsearches.NewSyncReport(results)
+	return savedsearches.WriteReport(os.Stdout, format, report, format == "text" && colorEnabled())
+}
+
+// runSync reconciles the config with GitHub, asking first if that would
+// delete anything.
+func runSync(ctx context.Context, opts *globalOptions, mode savedsearches.Mode, sf syncFlags) int {
+	output := *sf.output
+	if !validOutput(output) {
+		return 2
+	}
+
+	configPath, err := savedsearches.ResolveConfigPath(opts.config)
+	if err != nil {
+		log.Fatalf("resolve config path: %v", err)
+	}
+
+	progress := os.Stdout
+	if output != "text" {
+		// Keep stdout for the report.
+		progress = os.Stderr
+	}
+	syncers, err := accountSyncers(ctx, opts, configPath,
+		savedsearches.WithMode(mode),
+		savedsearches.WithVars(opts.vars),
+		savedsearches.WithLogger(opts.logger()),
+		savedsearches.WithObserver(savedsearches.ProgressObserver(progress)),
+		savedsearches.WithRateLimit(*sf.rps),
+		savedsearches.WithDelay(*sf.delay),
+		savedsearches.WithStrictLint(*sf.strict),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := confirmDeletions(ctx, syncers, configPath, *sf.yes); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Each syncer reloads the config, so IDs saved by one account's run are
+	// kept by the next.
+	var results []savedsearches.EntryResult
+	var syncErr error
+	for _, syncer := range syncers {
+		syncErr = syncer.Sync(ctx, configPath)
+		results = append(results, syncer.Results()...)
+		if syncErr != nil {
+			break
+		}
+	}
+	if err := writeReport(output, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if syncErr != nil {
+		fmt.Fprintln(os.Stderr, syncErr)
+		return 1
+	}
+	return 0
+}
+
+// accountSyncers builds a syncer for each account the run covers: the one
+// given by --account, or every account the config's searches use.
+func accountSyncers(ctx context.Context, opts *globalOptions, configPath string, syncOpts ...savedsearches.Option) ([]*savedsearches.Syncer, error) {
+	cfg, err := savedsearches.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := runAccounts(opts, cfg)
+	syncers := make([]*savedsearches.Syncer, 0, len(accounts))
+	for _, name := range accounts {
+		client, err := accountClient(ctx, opts, cfg, name)
+		if err != nil {
+			if name != "" {
+				return nil, fmt.Errorf("init client for account %s: %w", name, err)
+			}
+			return nil, fmt.Errorf("init client: %w", err)
+		}
+		syncers = append(syncers, savedsearches.NewSyncer(client, append([]savedsearches.Option{savedsearches.WithAccount(name)}, syncOpts...)...))
+	}
+	return syncers, nil
+}
+
+// planAll plans the run for every syncer, stopping at the first error.
+func planAll(ctx context.Context, syncers []*savedsearches.Syncer, configPath string) ([]savedsearches.EntryResult, error) {
+	var results []savedsearches.EntryResult
+	for _, syncer := range syncers {
+		planned, err := syncer.Plan(ctx, configPath)
+		results = append(results, planned...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// setupPlan prints the changes sync would make, without making them.
+func setupPlan(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	recreate := flags.Bool("recreate", false, "plan recreating all saved searches")
+	reset := flags.Bool("reset", false, "plan deleting all configured saved searches")
+	output := outputFlag(flags)
+	strict := strictFlag(flags)
+	return func(ctx context.Context, _ []string) int {
+		if !validOutput(*output) {
+			return 2
+		}
+		mode, ok := modeFromFlags(*recreate, *reset)
+		if !ok {
+			return 2
+		}
+
+		configPath, err := savedsearches.ResolveConfigPath(opts.config)
+		if err != nil {
+			log.Fatalf("resolve config path: %v", err)
+		}
+
+		syncers, err := accountSyncers(ctx, opts, configPath,
+			savedsearches.WithMode(mode),
+			savedsearches.WithVars(opts.vars),
+			savedsearches.WithLogger(opts.logger()),
+			savedsearches.WithStrictLint(*strict),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		results, planErr := planAll(ctx, syncers, configPath)
+		if err := writeReport(*output, results); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if planErr != nil {
+			fmt.Fprintln(os.Stderr, planErr)
+			return 1
+		}
+		return 0
+	}
+}
+
+// setupImport adds searches to the config from an export file, or from
+// GitHub when the client can list saved searches.
+func setupImport(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	format := flags.String("format", "", "import file format: "+strings.Join(savedsearches.ImportFormats, ", ")+" (default: from the file extension)")
+	return func(ctx context.Context, args []string) int {
+		if len(args) > 1 {
+			flags.Usage()
+			return 2
+		}
+
+		configPath, err := savedsearches.ResolveConfigPath(opts.config)
+		if err != nil {
+			log.Fatalf("resolve config path: %v", err)
+		}
+
+		cfg, err := savedsearches.LoadConfig(configPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatal(err)
+		}
+
+		var defs []savedsearches.SearchDefinition
+		if len(args) == 1 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				log.Fatalf("open import file: %v", err)
+			}
+			defer f.Close()
+
+			if *format == "" {
+				*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(args[0])), ".")
+			}
+			defs, err = savedsearches.ReadImport(f, *format)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		} else {
+			client, err := newClient(ctx, opts)
+			if err != nil {
+				log.Fatalf("init client: %v", err)
+			}
+			defs, err = savedsearches.ListLiveSearches(ctx, client)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+
+		cfg, added := savedsearches.ImportSearches(cfg, defs)
+		for _, name := range added {
+			fmt.Println("Imported: " + name)
+		}
+		if len(added) == 0 {
+			fmt.Println("Nothing to import.")
+			return 0
+		}
+
+		if err := savedsearches.SaveConfig(configPath, cfg); err != nil {
+			log.Fatal(err)
+		}
+		return 0
+	}
+}
+
+// setupValidate checks the config without contacting GitHub, beyond looking
+// up built-in vars. It exits non-zero when there are problems.
+func setupValidate(_ *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	return func(ctx context.Context, _ []string) int {
+		configPath, err := savedsearches.ResolveConfigPath(opts.config)
+		if err != nil {
+			log.Fatalf("resolve config path: %v", err)
+		}
+
+		cfg, err := savedsearches.LoadConfig(configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		if _, err := cfg.LookupAccount(opts.account); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		// Each account's searches render with its own built-in vars.
+		globals := map[string]map[string]any{}
+		for _, name := range runAccounts(opts, cfg) {
+			vars, err := cfg.ResolveVars(ctx, optionalClient(ctx, opts, cfg, name), opts.vars)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			globals[name] = vars
+		}
+
+		problems := savedsearches.ValidateAccounts(cfg, globals)
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		if len(problems) > 0 {
+			return 1
+		}
+		fmt.Printf("%s: ok\n", configPath)
+		return 0
+	}
+}
+
+// setupList prints every configured search with its section and ID.
+func setupList(_ *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	return func(ctx context.Context, _ []string) int {
+		views, err := loadAccountViews(ctx, opts, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var searches []savedsearches.RenderedSearch
+		for _, view := range views {
+			searches = append(searches, view.searches...)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSECTION\tID\tQUERY")
+		for _, s := range searches {
+			if s.Header {
+				continue
+			}
+			id := s.ID
+			if id == "" {
+				id = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.Section, id, truncate(s.Query, 80))
+		}
+		tw.Flush()
+		return 0
+	}
+}
+
+// setupLint renders every configured query and reports problems. It exits
+// non-zero when any query has errors.
+func setupLint(_ *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	return func(ctx context.Context, _ []string) int {
+		views, err := loadAccountViews(ctx, opts, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var results []savedsearches.LintResult
+		for _, view := range views {
+			results = append(results, savedsearches.LintSearches(view.searches)...)
+		}
+
+		failed := false
+		for _, r := range results {
+			for _, d := range r.Diagnostics {
+				fmt.Printf("%s: %s\n", r.Name, d)
+			}
+			if query.HasErrors(r.Diagnostics) {
+				failed = true
+			}
+		}
+
+		if failed {
+			return 1
+		}
+		return 0
+	}
+}
+
+// setupFmt rewrites the config's queries in canonical form. With --check it
+// only reports entries that need formatting and exits non-zero if any do.
+func setupFmt(flags *flag.FlagSet, opts *globalOptions) func(context.Context, []string) int {
+	check := flags.Bool("check", false, "report unformatted queries without rewriting the config")
+	return func(_ context.Context, _ []string) int {
+		configPath, err := savedsearches.ResolveConfigPath(opts.config)
+		if err != nil {
+			log.Fatalf("resolve config path: %v", err)
+		}
+
+		cfg, err := savedsearches.LoadConfig(configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		formatted, changed := savedsearches.FormatConfig(cfg)
+		for _, name := range changed {
+			fmt.Println("Formatted: " + name)
+		}
+
+		if *check {
+			if len(changed) > 0 {
+				return 1
+			}
+			return 0
+		}
+
+		if len(changed) > 0 {
+			if err := savedsearches.SaveConfig(configPath, formatted); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return 0
+	}
+}